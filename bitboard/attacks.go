@@ -0,0 +1,116 @@
+package bitboard
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Squares are numbered like FromIndex and ToIndex in Move: h1 is 0, a1 is 7 and a8 is 63.
+
+var (
+	knightAttacks [64]Bitboard
+	kingAttacks   [64]Bitboard
+	pawnAttacks   [2][64]Bitboard
+
+	rookMagics   [64]magic
+	bishopMagics [64]magic
+
+	rookTable   [102400]Bitboard
+	bishopTable [5248]Bitboard
+)
+
+// magic finds the attacks of a slider on one square. The occupied squares that can block it are multiplied
+// by a number chosen so the top bits of the product are different for every blocker configuration that gives different attacks.
+type magic struct {
+	mask    Bitboard
+	magic   uint64
+	shift   uint8
+	attacks []Bitboard
+}
+
+func (m *magic) index(occupied Bitboard) uint64 {
+	return uint64(occupied&m.mask) * m.magic >> m.shift
+}
+
+func init() {
+	for square := uint8(0); square < 64; square++ {
+		knightAttacks[square] = knightMoves(1<<square, 0)
+		kingAttacks[square] = kingMoves(1<<square, 0)
+		pawnAttacks[0][square] = whitePawnAttacks(1 << square)
+		pawnAttacks[1][square] = blackPawnAttacks(1 << square)
+	}
+	fillMagics(&rookMagics, rookTable[:], rookMagicNumbers, rookMask, slidingRookAttacks)
+	fillMagics(&bishopMagics, bishopTable[:], bishopMagicNumbers, bishopMask, slidingBishopAttacks)
+}
+
+func fillMagics(magics *[64]magic, table []Bitboard, numbers [64]uint64, mask func(uint8) Bitboard, attacks func(Bitboard, Bitboard) Bitboard) {
+	offset := 0
+	for square := uint8(0); square < 64; square++ {
+		m := &magics[square]
+		m.mask = mask(square)
+		m.magic = numbers[square]
+		size := 1 << bits.OnesCount64(uint64(m.mask))
+		m.shift = uint8(64 - bits.OnesCount64(uint64(m.mask)))
+		m.attacks = table[offset : offset+size]
+		offset += size
+
+		//Walk through every subset of the mask. Blocker sets may share an index only if they give the same attacks,
+		//and an empty slot is zero since a slider always attacks at least one square.
+		occupied := Bitboard(0)
+		for {
+			index := m.index(occupied)
+			attacked := attacks(1<<square, occupied)
+			if m.attacks[index] != 0 && m.attacks[index] != attacked {
+				panic(fmt.Sprintf("magic number for square %d maps blockers %#x to a slot with other attacks", square, occupied))
+			}
+			m.attacks[index] = attacked
+			occupied = (occupied - m.mask) & m.mask
+			if occupied == 0 {
+				break
+			}
+		}
+	}
+}
+
+// rookMask is the squares whose pieces can block a rook on square. The last square of a ray never blocks anything behind it.
+func rookMask(square uint8) Bitboard {
+	empty := ^Bitboard(0)
+	vertical := (upAttacks(1<<square, empty) | downAttacks(1<<square, empty)) &^ (maskRank[rank1] | maskRank[rank8])
+	horizontal := (leftAttacks(1<<square, empty) | rightAttacks(1<<square, empty)) &^ (maskFile[fileA] | maskFile[fileH])
+	return vertical | horizontal
+}
+
+func bishopMask(square uint8) Bitboard {
+	edges := maskRank[rank1] | maskRank[rank8] | maskFile[fileA] | maskFile[fileH]
+	return slidingBishopAttacks(1<<square, 0) &^ edges
+}
+
+// RookAttacks returns the squares a rook on square attacks when the pieces in occupied block it.
+// The attacked squares include the first blocker of every ray, whichever side it belongs to.
+func RookAttacks(square uint8, occupied Bitboard) Bitboard {
+	m := &rookMagics[square]
+	return m.attacks[m.index(occupied)]
+}
+
+// BishopAttacks returns the squares a bishop on square attacks, like RookAttacks
+func BishopAttacks(square uint8, occupied Bitboard) Bitboard {
+	m := &bishopMagics[square]
+	return m.attacks[m.index(occupied)]
+}
+
+func QueenAttacks(square uint8, occupied Bitboard) Bitboard {
+	return RookAttacks(square, occupied) | BishopAttacks(square, occupied)
+}
+
+func KnightAttacks(square uint8) Bitboard {
+	return knightAttacks[square]
+}
+
+func KingAttacks(square uint8) Bitboard {
+	return kingAttacks[square]
+}
+
+// PawnAttacks returns the squares a pawn of the given color on square attacks
+func PawnAttacks(square uint8, black bool) Bitboard {
+	return pawnAttacks[sideIndex(black)][square]
+}
@@ -0,0 +1,59 @@
+package bitboard
+
+import "testing"
+
+// checkSliders compares the table lookups with the flood fills for every square and every set of blockers in its mask.
+// Pieces outside the mask must not change the result.
+func checkSliders(t *testing.T, name string, magics *[64]magic, lookup func(uint8, Bitboard) Bitboard, attacks func(Bitboard, Bitboard) Bitboard) {
+	for square := uint8(0); square < 64; square++ {
+		mask := magics[square].mask
+		outside := ^mask &^ (1 << square)
+		occupied := Bitboard(0)
+		for {
+			want := attacks(1<<square, occupied)
+			if got := lookup(square, occupied); got != want {
+				t.Fatalf("%s on square %d with blockers %#x: got %#x, want %#x", name, square, occupied, got, want)
+			}
+			if got := lookup(square, occupied|outside); got != want {
+				t.Fatalf("%s on square %d with blockers %#x and every square outside the mask: got %#x, want %#x", name, square, occupied, got, want)
+			}
+			occupied = (occupied - mask) & mask
+			if occupied == 0 {
+				break
+			}
+		}
+	}
+}
+
+func TestSliderAttacks(t *testing.T) {
+	checkSliders(t, "rook", &rookMagics, RookAttacks, slidingRookAttacks)
+	checkSliders(t, "bishop", &bishopMagics, BishopAttacks, slidingBishopAttacks)
+}
+
+// benchmarkOccupancies are the boards of the benchmark positions, with every square of each as the slider's square
+func benchmarkOccupancies(b *testing.B) []Bitboard {
+	var occupancies []Bitboard
+	for _, position := range benchmarkPositions {
+		board, err := ParseFEN(position.fen)
+		if err != nil {
+			b.Fatal(err)
+		}
+		occupancies = append(occupancies, board.AllPieces)
+	}
+	return occupancies
+}
+
+func BenchmarkRookAttacks(b *testing.B) {
+	occupancies := benchmarkOccupancies(b)
+	var attacked Bitboard
+	for i := 0; i < b.N; i++ {
+		for _, occupied := range occupancies {
+			for square := uint8(0); square < 64; square++ {
+				attacked ^= RookAttacks(square, occupied)
+			}
+		}
+	}
+	if attacked == 1 {
+		b.Log(attacked)
+	}
+}
@@ -1,9 +1,6 @@
 package bitboard
 
-import (
-	"strings"
-	"unicode"
-)
+import "math/bits"
 
 type Bitboard uint64
 
@@ -22,8 +19,6 @@ type ChessBoard struct {
 	BlackQueens  Bitboard
 	BlackKing    Bitboard
 
-	AllBitboards [12]*Bitboard
-
 	AllWhitePieces Bitboard
 	AllBlackPieces Bitboard
 	AllPieces      Bitboard
@@ -38,6 +33,9 @@ type ChessBoard struct {
 
 	BlacksTurn bool
 
+	HalfmoveClock  int
+	FullmoveNumber int
+
 	Zobrist uint64
 
 	LastHashes []uint64
@@ -187,28 +185,28 @@ func (board *ChessBoard) PsudoLegalMoves(onlyCaptures bool) []Move {
 		}
 
 		for _, From := range BitboardToSlice(board.WhiteRooks) {
-			moves := rookMoves(1<<From, board.AllPieces, board.AllWhitePieces) & andWith
+			moves := RookAttacks(From, board.AllPieces) &^ board.AllWhitePieces & andWith
 			for _, To := range BitboardToSlice(moves) {
 				psudomoves = append(psudomoves, Move{From: 1 << From, To: 1 << To, FromIndex: From, ToIndex: To, Piece: WhiteRook})
 			}
 		}
 
 		for _, From := range BitboardToSlice(board.WhiteKnights) {
-			moves := knightMoves(1<<From, board.AllWhitePieces) & andWith
+			moves := KnightAttacks(From) &^ board.AllWhitePieces & andWith
 			for _, To := range BitboardToSlice(moves) {
 				psudomoves = append(psudomoves, Move{From: 1 << From, To: 1 << To, FromIndex: From, ToIndex: To, Piece: WhiteKnight})
 			}
 		}
 
 		for _, From := range BitboardToSlice(board.WhiteBishops) {
-			moves := bishopMoves(1<<From, board.AllPieces, board.AllWhitePieces) & andWith
+			moves := BishopAttacks(From, board.AllPieces) &^ board.AllWhitePieces & andWith
 			for _, To := range BitboardToSlice(moves) {
 				psudomoves = append(psudomoves, Move{From: 1 << From, To: 1 << To, FromIndex: From, ToIndex: To, Piece: WhiteBishop})
 			}
 		}
 
 		for _, From := range BitboardToSlice(board.WhiteQueens) {
-			moves := queenMoves(1<<From, board.AllPieces, board.AllWhitePieces) & andWith
+			moves := QueenAttacks(From, board.AllPieces) &^ board.AllWhitePieces & andWith
 			for _, To := range BitboardToSlice(moves) {
 				psudomoves = append(psudomoves, Move{From: 1 << From, To: 1 << To, FromIndex: From, ToIndex: To, Piece: WhiteQueen})
 			}
@@ -242,28 +240,28 @@ func (board *ChessBoard) PsudoLegalMoves(onlyCaptures bool) []Move {
 		}
 
 		for _, From := range BitboardToSlice(board.BlackRooks) {
-			moves := rookMoves(1<<From, board.AllPieces, board.AllBlackPieces) & andWith
+			moves := RookAttacks(From, board.AllPieces) &^ board.AllBlackPieces & andWith
 			for _, To := range BitboardToSlice(moves) {
 				psudomoves = append(psudomoves, Move{From: 1 << From, To: 1 << To, FromIndex: From, ToIndex: To, Piece: BlackRook})
 			}
 		}
 
 		for _, From := range BitboardToSlice(board.BlackKnights) {
-			moves := knightMoves(1<<From, board.AllBlackPieces) & andWith
+			moves := KnightAttacks(From) &^ board.AllBlackPieces & andWith
 			for _, To := range BitboardToSlice(moves) {
 				psudomoves = append(psudomoves, Move{From: 1 << From, To: 1 << To, FromIndex: From, ToIndex: To, Piece: BlackKnight})
 			}
 		}
 
 		for _, From := range BitboardToSlice(board.BlackBishops) {
-			moves := bishopMoves(1<<From, board.AllPieces, board.AllBlackPieces) & andWith
+			moves := BishopAttacks(From, board.AllPieces) &^ board.AllBlackPieces & andWith
 			for _, To := range BitboardToSlice(moves) {
 				psudomoves = append(psudomoves, Move{From: 1 << From, To: 1 << To, FromIndex: From, ToIndex: To, Piece: BlackBishop})
 			}
 		}
 
 		for _, From := range BitboardToSlice(board.BlackQueens) {
-			moves := queenMoves(1<<From, board.AllPieces, board.AllBlackPieces) & andWith
+			moves := QueenAttacks(From, board.AllPieces) &^ board.AllBlackPieces & andWith
 			for _, To := range BitboardToSlice(moves) {
 				psudomoves = append(psudomoves, Move{From: 1 << From, To: 1 << To, FromIndex: From, ToIndex: To, Piece: BlackQueen})
 			}
@@ -285,7 +283,20 @@ func (board *ChessBoard) PsudoLegalMoves(onlyCaptures bool) []Move {
 	return psudomoves
 }
 
+// DoMove plays m, which must be a pseudo-legal move
 func (board *ChessBoard) DoMove(m Move) {
+	if m.Piece == WhitePawn || m.Piece == BlackPawn || board.AllPieces&m.To > 0 {
+		board.HalfmoveClock = 0
+		//Positions before an irreversible move can not come back. The new history starts after the old one,
+		//so a copy of the board from before the move still has it.
+		board.LastHashes = board.LastHashes[len(board.LastHashes):]
+	} else {
+		board.HalfmoveClock++
+	}
+	if board.BlacksTurn {
+		board.FullmoveNumber++
+	}
+
 	if board.WhiteEnPassant != 8 {
 		board.Zobrist ^= enPassantHashes[board.WhiteEnPassant]
 		board.WhiteEnPassant = 8
@@ -310,7 +321,6 @@ func (board *ChessBoard) DoMove(m Move) {
 			board.WhiteEnPassant = m.FromIndex % 8
 			board.Zobrist ^= enPassantHashes[m.FromIndex%8]
 		}
-		board.LastHashes = []uint64{}
 	} else if m.Piece == WhiteRook {
 		board.DeleteOnSquare(m.To, m.ToIndex)
 		board.WhiteRooks = (board.WhiteRooks & ^m.From) | m.To
@@ -363,7 +373,6 @@ func (board *ChessBoard) DoMove(m Move) {
 			board.BlackEnPassant = m.FromIndex % 8
 			board.Zobrist ^= enPassantHashes[m.FromIndex%8]
 		}
-		board.LastHashes = []uint64{}
 	} else if m.Piece == BlackRook {
 		board.DeleteOnSquare(m.To, m.ToIndex)
 		board.BlackRooks = (board.BlackRooks & ^m.From) | m.To
@@ -418,8 +427,15 @@ func (board *ChessBoard) DoMove(m Move) {
 		board.Zobrist ^= blackShortCastleHash
 	}
 
-	board.AllWhitePieces = board.WhitePawns | board.WhiteRooks | board.WhiteKnights | board.WhiteBishops | board.WhiteQueens | board.WhiteKing
-	board.AllBlackPieces = board.BlackPawns | board.BlackRooks | board.BlackKnights | board.BlackBishops | board.BlackQueens | board.BlackKing
+	//Only the squares the move touched change in the unions
+	moved := m.From | m.To | castlingRookSquares(m)
+	if board.BlacksTurn {
+		board.AllBlackPieces ^= moved
+		board.AllWhitePieces &^= captureSquare(m)
+	} else {
+		board.AllWhitePieces ^= moved
+		board.AllBlackPieces &^= captureSquare(m)
+	}
 	board.AllPieces = board.AllWhitePieces | board.AllBlackPieces
 
 	board.BlacksTurn = !board.BlacksTurn
@@ -428,8 +444,53 @@ func (board *ChessBoard) DoMove(m Move) {
 	board.LastHashes = append(board.LastHashes, board.Zobrist)
 }
 
+// DoNullMove passes the turn to the opponent without moving. Repetitions before the null move are forgotten,
+// since the null move is not a legal move that could lead back to them.
+func (board *ChessBoard) DoNullMove() {
+	board.HalfmoveClock++
+	if board.BlacksTurn {
+		board.FullmoveNumber++
+	}
+	if board.WhiteEnPassant != 8 {
+		board.Zobrist ^= enPassantHashes[board.WhiteEnPassant]
+		board.WhiteEnPassant = 8
+	}
+	if board.BlackEnPassant != 8 {
+		board.Zobrist ^= enPassantHashes[board.BlackEnPassant]
+		board.BlackEnPassant = 8
+	}
+	board.BlacksTurn = !board.BlacksTurn
+	board.Zobrist ^= blacksTurnHash
+	board.LastHashes = append(board.LastHashes[len(board.LastHashes):], board.Zobrist)
+}
+
+// captureSquare returns the square of the piece m captures if it is a capture, which is behind the destination for en passant
+func captureSquare(m Move) Bitboard {
+	if !m.EnPassant {
+		return m.To
+	}
+	if m.Piece == WhitePawn {
+		return m.To >> 8
+	}
+	return m.To << 8
+}
+
+// castlingRookSquares returns the squares the rook moves between when m castles, and 0 for other moves
+func castlingRookSquares(m Move) Bitboard {
+	switch {
+	case m.Piece == WhiteKing && m.LongCastle:
+		return a1 | d1
+	case m.Piece == WhiteKing && m.ShortCastle:
+		return h1 | f1
+	case m.Piece == BlackKing && m.LongCastle:
+		return a8 | d8
+	case m.Piece == BlackKing && m.ShortCastle:
+		return h8 | f8
+	}
+	return 0
+}
+
 func (board *ChessBoard) DeleteOnSquare(square Bitboard, index uint8) PieceType {
-	deleted := true
 	if board.WhitePawns&square > 0 {
 		board.WhitePawns &= ^square
 		board.Zobrist ^= positionHashes[WhitePawn-1][index]
@@ -478,15 +539,73 @@ func (board *ChessBoard) DeleteOnSquare(square Bitboard, index uint8) PieceType
 		board.BlackKing &= ^square
 		board.Zobrist ^= positionHashes[BlackKing-1][index]
 		return BlackKing
-	} else {
-		deleted = false
 	}
-	if deleted {
-		board.LastHashes = []uint64{}
+	return 0
+}
+
+func (board *ChessBoard) PieceOnSquare(square Bitboard) PieceType {
+	if board.WhitePawns&square > 0 {
+		return WhitePawn
+	} else if board.WhiteRooks&square > 0 {
+		return WhiteRook
+	} else if board.WhiteKnights&square > 0 {
+		return WhiteKnight
+	} else if board.WhiteBishops&square > 0 {
+		return WhiteBishop
+	} else if board.WhiteQueens&square > 0 {
+		return WhiteQueen
+	} else if board.WhiteKing&square > 0 {
+		return WhiteKing
+	} else if board.BlackPawns&square > 0 {
+		return BlackPawn
+	} else if board.BlackRooks&square > 0 {
+		return BlackRook
+	} else if board.BlackKnights&square > 0 {
+		return BlackKnight
+	} else if board.BlackBishops&square > 0 {
+		return BlackBishop
+	} else if board.BlackQueens&square > 0 {
+		return BlackQueen
+	} else if board.BlackKing&square > 0 {
+		return BlackKing
 	}
 	return 0
 }
 
+func (board *ChessBoard) pieceBitboard(piece PieceType) *Bitboard {
+	switch piece {
+	case WhitePawn:
+		return &board.WhitePawns
+	case WhiteRook:
+		return &board.WhiteRooks
+	case WhiteKnight:
+		return &board.WhiteKnights
+	case WhiteBishop:
+		return &board.WhiteBishops
+	case WhiteQueen:
+		return &board.WhiteQueens
+	case WhiteKing:
+		return &board.WhiteKing
+	case BlackPawn:
+		return &board.BlackPawns
+	case BlackRook:
+		return &board.BlackRooks
+	case BlackKnight:
+		return &board.BlackKnights
+	case BlackBishop:
+		return &board.BlackBishops
+	case BlackQueen:
+		return &board.BlackQueens
+	case BlackKing:
+		return &board.BlackKing
+	}
+	return nil
+}
+
+func (board *ChessBoard) setPiece(piece PieceType, square Bitboard) {
+	*board.pieceBitboard(piece) |= square
+}
+
 func (board *ChessBoard) CheckForCheck(whiteSide bool) bool {
 	board.AllWhitePieces = board.WhitePawns | board.WhiteRooks | board.WhiteKnights | board.WhiteBishops | board.WhiteQueens | board.WhiteKing
 	board.AllBlackPieces = board.BlackPawns | board.BlackRooks | board.BlackKnights | board.BlackBishops | board.BlackQueens | board.BlackKing
@@ -525,6 +644,7 @@ func (board *ChessBoard) BlackAttacking() Bitboard {
 	return attacking
 }
 
+// PromotePawn replaces the pawn on square with newType, which must be a piece of the same color
 func (board *ChessBoard) PromotePawn(square Bitboard, newType PieceType) {
 	if newType == WhiteQueen {
 		board.WhitePawns &= ^square
@@ -550,23 +670,24 @@ func (board *ChessBoard) PromotePawn(square Bitboard, newType PieceType) {
 	} else if newType == BlackKnight {
 		board.BlackPawns &= ^square
 		board.BlackKnights |= square
+	} else {
+		return
 	}
-}
 
-func (board *ChessBoard) Init() {
-	board.AllBitboards[0] = &board.WhitePawns
-	board.AllBitboards[1] = &board.WhiteRooks
-	board.AllBitboards[2] = &board.WhiteKnights
-	board.AllBitboards[3] = &board.WhiteBishops
-	board.AllBitboards[4] = &board.WhiteQueens
-	board.AllBitboards[5] = &board.WhiteKing
-	board.AllBitboards[6] = &board.BlackPawns
-	board.AllBitboards[7] = &board.BlackRooks
-	board.AllBitboards[8] = &board.BlackKnights
-	board.AllBitboards[9] = &board.BlackBishops
-	board.AllBitboards[10] = &board.BlackQueens
-	board.AllBitboards[11] = &board.BlackKing
+	pawn := WhitePawn
+	if newType >= BlackPawn {
+		pawn = BlackPawn
+	}
+	index := bits.TrailingZeros64(uint64(square))
+	board.Zobrist ^= positionHashes[pawn-1][index] ^ positionHashes[newType-1][index]
+}
 
+// pieceBitboards returns the bitboards of every piece type, indexed by the piece type minus one
+func (board *ChessBoard) pieceBitboards() [12]Bitboard {
+	return [12]Bitboard{
+		board.WhitePawns, board.WhiteRooks, board.WhiteKnights, board.WhiteBishops, board.WhiteQueens, board.WhiteKing,
+		board.BlackPawns, board.BlackRooks, board.BlackKnights, board.BlackBishops, board.BlackQueens, board.BlackKing,
+	}
 }
 
 func (board *ChessBoard) InitVariables() {
@@ -574,93 +695,10 @@ func (board *ChessBoard) InitVariables() {
 	board.AllBlackPieces = board.BlackPawns | board.BlackRooks | board.BlackKnights | board.BlackBishops | board.BlackQueens | board.BlackKing
 	board.AllPieces = board.AllWhitePieces | board.AllBlackPieces
 
-	board.WhiteEnPassant = 8
-	board.BlackEnPassant = 8
-
 	board.Zobrist = boardToHash(board)
 	board.LastHashes = []uint64{board.Zobrist}
 }
 
-func FenString(fen string) ChessBoard {
-	parts := strings.Split(fen, " ")
-
-	x := 0
-	y := 0
-	var board ChessBoard
-	for _, char := range parts[0] {
-		if char == '/' {
-			x = 0
-			y++
-		} else if unicode.IsDigit(char) {
-			x += int(char) - '0'
-		} else {
-			switch char {
-			case 'P':
-				board.WhitePawns |= 1 << (63 - (y*8 + x))
-			case 'R':
-				board.WhiteRooks |= 1 << (63 - (y*8 + x))
-			case 'N':
-				board.WhiteKnights |= 1 << (63 - (y*8 + x))
-			case 'B':
-				board.WhiteBishops |= 1 << (63 - (y*8 + x))
-			case 'Q':
-				board.WhiteQueens |= 1 << (63 - (y*8 + x))
-			case 'K':
-				board.WhiteKing |= 1 << (63 - (y*8 + x))
-			case 'p':
-				board.BlackPawns |= 1 << (63 - (y*8 + x))
-			case 'r':
-				board.BlackRooks |= 1 << (63 - (y*8 + x))
-			case 'n':
-				board.BlackKnights |= 1 << (63 - (y*8 + x))
-			case 'b':
-				board.BlackBishops |= 1 << (63 - (y*8 + x))
-			case 'q':
-				board.BlackQueens |= 1 << (63 - (y*8 + x))
-			case 'k':
-				board.BlackKing |= 1 << (63 - (y*8 + x))
-			}
-			x++
-		}
-	}
-	if len(parts) >= 4 {
-		if parts[1] == "w" {
-			board.BlacksTurn = false
-		} else if parts[1] == "b" {
-			board.BlacksTurn = true
-		}
-
-		for _, char := range parts[2] {
-			if char == 'Q' {
-				board.WhiteLongCastle = true
-			} else if char == 'K' {
-				board.WhiteShortCastle = true
-			} else if char == 'q' {
-				board.BlackLongCastle = true
-			} else if char == 'k' {
-				board.BlackShortCastle = true
-			}
-		}
-
-		/*if len(parts[3]) > 1 {
-			var rank Bitboard
-			if parts[3][0] == '3' {
-				rank = maskRank[rank2]
-				switch parts[3][1] {
-				case 'a':
-
-				}
-			} else if parts[3][0] == '6' {
-				rank = maskRank[rank7]
-			}
-
-
-		}*/
-	}
-
-	return board
-}
-
 func CoordsToBitboard(x, y int) Bitboard {
 	return maskFile[x] & maskRank[7-y]
 }
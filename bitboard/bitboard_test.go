@@ -0,0 +1,26 @@
+package bitboard
+
+import "testing"
+
+func TestPromotionZobrist(t *testing.T) {
+	//Both sides can promote, to an empty square or by capturing
+	for _, fen := range []string{
+		"1n2k3/P7/8/8/8/8/7p/4K1N1 w - - 0 1",
+		"1n2k3/P7/8/8/8/8/7p/4K1N1 b - - 0 1",
+	} {
+		board, err := ParseFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range board.LegalMoves() {
+			if m.PawnPromotionPiece == 0 {
+				continue
+			}
+			after := board
+			after.DoMove(m)
+			if hash := boardToHash(&after); after.Zobrist != hash {
+				t.Errorf("%s after %s: Zobrist is %016x, recomputing gives %016x", fen, m.UCI(), after.Zobrist, hash)
+			}
+		}
+	}
+}
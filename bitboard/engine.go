@@ -0,0 +1,167 @@
+package bitboard
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+const (
+	MaxThreads int = 256
+	MaxMultiPV int = 256
+)
+
+// Options configure an Engine, zero values select the defaults
+type Options struct {
+	Hash    int // transposition table size in megabytes
+	Threads int // number of goroutines searching in parallel
+	MultiPV int // number of best lines to report
+
+	Disabled Feature // search techniques that are switched off
+}
+
+// Stats counts the work done by the last search of an Engine
+type Stats struct {
+	Nodes           uint64
+	QuiescenceNodes uint64
+	TTHits          uint64
+	BetaCutoffs     uint64
+}
+
+// Engine searches chess positions. Every engine owns its transposition table, history and statistics,
+// so several engines can be used at the same time, but one engine runs only one search at a time.
+type Engine struct {
+	options Options
+	tt      *TranspositionTable
+	history [2][64][64]int32
+	age     uint8
+
+	stopped   int32
+	nodeLimit uint64
+	stats     Stats
+}
+
+func NewEngine(opts Options) *Engine {
+	if opts.Hash <= 0 {
+		opts.Hash = DefaultHashSize
+	}
+	if opts.Hash > MaxHashSize {
+		opts.Hash = MaxHashSize
+	}
+	opts.Threads = clampThreads(opts.Threads)
+	opts.MultiPV = clampMultiPV(opts.MultiPV)
+	return &Engine{options: opts, tt: NewTranspositionTable(opts.Hash)}
+}
+
+func (e *Engine) Options() Options {
+	return e.options
+}
+
+// SetHash resizes the transposition table, which clears it. It must not be called during a search.
+func (e *Engine) SetHash(megabytes int) {
+	if megabytes <= 0 {
+		megabytes = DefaultHashSize
+	}
+	if megabytes > MaxHashSize {
+		megabytes = MaxHashSize
+	}
+	e.options.Hash = megabytes
+	e.tt.Resize(megabytes)
+}
+
+// SetThreads changes the number of threads used by later searches
+func (e *Engine) SetThreads(threads int) {
+	e.options.Threads = clampThreads(threads)
+}
+
+func clampThreads(threads int) int {
+	if threads < 1 {
+		return 1
+	}
+	if threads > MaxThreads {
+		return MaxThreads
+	}
+	return threads
+}
+
+// SetMultiPV changes the number of lines reported by later searches
+func (e *Engine) SetMultiPV(lines int) {
+	e.options.MultiPV = clampMultiPV(lines)
+}
+
+func clampMultiPV(lines int) int {
+	if lines < 1 {
+		return 1
+	}
+	if lines > MaxMultiPV {
+		return MaxMultiPV
+	}
+	return lines
+}
+
+// NewGame forgets everything learned from earlier searches. It must not be called during a search.
+func (e *Engine) NewGame() {
+	e.tt.Clear()
+	e.history = [2][64][64]int32{}
+	e.age = 0
+}
+
+// Stats can be called during a search to see its progress
+func (e *Engine) Stats() Stats {
+	return Stats{
+		Nodes:           atomic.LoadUint64(&e.stats.Nodes),
+		QuiescenceNodes: atomic.LoadUint64(&e.stats.QuiescenceNodes),
+		TTHits:          atomic.LoadUint64(&e.stats.TTHits),
+		BetaCutoffs:     atomic.LoadUint64(&e.stats.BetaCutoffs),
+	}
+}
+
+// Hashfull is how many permille of the transposition table hold results of the last search
+func (e *Engine) Hashfull() int {
+	return e.tt.Hashfull(e.age)
+}
+
+// Stop makes the running search return as soon as possible with the best move found so far
+func (e *Engine) Stop() {
+	atomic.StoreInt32(&e.stopped, 1)
+}
+
+func (e *Engine) isStopped() bool {
+	return atomic.LoadInt32(&e.stopped) != 0
+}
+
+// start resets the engine for a new search and stops it when ctx is done. The returned function must be called when the search ends.
+func (e *Engine) start(ctx context.Context, nodeLimit uint64) (end func()) {
+	atomic.StoreInt32(&e.stopped, 0)
+	atomic.StoreUint64(&e.stats.Nodes, 0)
+	atomic.StoreUint64(&e.stats.QuiescenceNodes, 0)
+	atomic.StoreUint64(&e.stats.TTHits, 0)
+	atomic.StoreUint64(&e.stats.BetaCutoffs, 0)
+	e.nodeLimit = nodeLimit
+	e.age++
+
+	//Old history is still useful for ordering, but should not outweigh what this search learns
+	for side := range e.history {
+		for from := range e.history[side] {
+			for to := range e.history[side][from] {
+				e.history[side][from][to] /= 8
+			}
+		}
+	}
+
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	if ctx.Err() != nil {
+		e.Stop()
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			e.Stop()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -8,6 +8,9 @@ var (
 	mgValues     [6]int32 = [6]int32{82, 477, 337, 365, 1025, 0}
 	egValues     [6]int32 = [6]int32{94, 512, 281, 297, 936, 0}
 	gamePhaseInc [6]int32 = [6]int32{0, 2, 1, 1, 4, 0}
+
+	//Only written by init, so it is safe to share between engines
+	numberToBits [8][256][]uint8
 )
 
 type pieceSquareTable struct {
@@ -227,14 +230,15 @@ func evaluate(board *ChessBoard) int32 {
 	egScore := int32(0)
 	mgPhase := int32(0)
 
+	pieces := board.pieceBitboards()
 	for i := 0; i < 12; i++ {
-		count := int32(bits.OnesCount64(uint64(*board.AllBitboards[i])))
+		count := int32(bits.OnesCount64(uint64(pieces[i])))
 		piece := i % 6
 		if i < 6 {
 			mgScore += count * mgValues[piece]
 			egScore += count * egValues[piece]
 			for j := 0; j < 8; j++ {
-				index := (*board.AllBitboards[i] >> (j * 8)) & maskRank[rank1]
+				index := (pieces[i] >> (j * 8)) & maskRank[rank1]
 				mgScore += mgTables[piece].lookup[j][index]
 				egScore += egTables[piece].lookup[j][index]
 			}
@@ -242,7 +246,7 @@ func evaluate(board *ChessBoard) int32 {
 			mgScore -= count * mgValues[piece]
 			egScore -= count * egValues[piece]
 			for j := 0; j < 8; j++ {
-				index := (*board.AllBitboards[i] >> ((7 - j) * 8)) & maskRank[rank1]
+				index := (pieces[i] >> ((7 - j) * 8)) & maskRank[rank1]
 				mgScore -= mgTables[piece].lookup[j][index]
 				egScore -= egTables[piece].lookup[j][index]
 			}
@@ -0,0 +1,57 @@
+package bitboard
+
+// Feature is a search technique that can be switched off, so its effect can be measured in self-play
+type Feature uint32
+
+const (
+	NullMovePruning          Feature = 1 << 0
+	LateMoveReductions       Feature = 1 << 1
+	PrincipalVariationSearch Feature = 1 << 2
+	AspirationWindows        Feature = 1 << 3
+	CheckExtensions          Feature = 1 << 4
+	FutilityPruning          Feature = 1 << 5
+	ReverseFutilityPruning   Feature = 1 << 6
+	DeltaPruning             Feature = 1 << 7
+	SEEPruning               Feature = 1 << 8
+)
+
+// mateSearchDisabled are switched off while searching for a mate, since they can prune away the moves of a forced mate
+const mateSearchDisabled Feature = NullMovePruning | LateMoveReductions | FutilityPruning | ReverseFutilityPruning | DeltaPruning | SEEPruning
+
+// Features lists every Feature, all of them are enabled by default
+var Features []Feature = []Feature{
+	NullMovePruning, LateMoveReductions, PrincipalVariationSearch, AspirationWindows,
+	CheckExtensions, FutilityPruning, ReverseFutilityPruning, DeltaPruning, SEEPruning,
+}
+
+var featureNames map[Feature]string = map[Feature]string{
+	NullMovePruning:          "Null Move Pruning",
+	LateMoveReductions:       "Late Move Reductions",
+	PrincipalVariationSearch: "Principal Variation Search",
+	AspirationWindows:        "Aspiration Windows",
+	CheckExtensions:          "Check Extensions",
+	FutilityPruning:          "Futility Pruning",
+	ReverseFutilityPruning:   "Reverse Futility Pruning",
+	DeltaPruning:             "Delta Pruning",
+	SEEPruning:               "SEE Pruning",
+}
+
+func (f Feature) String() string {
+	if name, ok := featureNames[f]; ok {
+		return name
+	}
+	return "Unknown Feature"
+}
+
+// SetFeature switches a feature on or off for later searches
+func (e *Engine) SetFeature(feature Feature, enabled bool) {
+	if enabled {
+		e.options.Disabled &^= feature
+	} else {
+		e.options.Disabled |= feature
+	}
+}
+
+func (e *Engine) enabled(feature Feature) bool {
+	return e.options.Disabled&feature == 0
+}
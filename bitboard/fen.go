@@ -0,0 +1,368 @@
+package bitboard
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var pieceToFen map[PieceType]byte = map[PieceType]byte{
+	WhitePawn: 'P', WhiteRook: 'R', WhiteKnight: 'N', WhiteBishop: 'B', WhiteQueen: 'Q', WhiteKing: 'K',
+	BlackPawn: 'p', BlackRook: 'r', BlackKnight: 'n', BlackBishop: 'b', BlackQueen: 'q', BlackKing: 'k',
+}
+
+var fenToPiece map[rune]PieceType = map[rune]PieceType{
+	'P': WhitePawn, 'R': WhiteRook, 'N': WhiteKnight, 'B': WhiteBishop, 'Q': WhiteQueen, 'K': WhiteKing,
+	'p': BlackPawn, 'r': BlackRook, 'n': BlackKnight, 'b': BlackBishop, 'q': BlackQueen, 'k': BlackKing,
+}
+
+// FenString parses fen without reporting errors, anything it does not understand is skipped.
+// Use ParseFEN for input that is not known to be valid.
+func FenString(fen string) ChessBoard {
+	parts := strings.Fields(fen)
+
+	var board ChessBoard
+	board.WhiteEnPassant = 8
+	board.BlackEnPassant = 8
+	board.FullmoveNumber = 1
+	if len(parts) == 0 {
+		return board
+	}
+
+	x := 0
+	y := 0
+	for _, char := range parts[0] {
+		if char == '/' {
+			x = 0
+			y++
+		} else if unicode.IsDigit(char) {
+			x += int(char) - '0'
+		} else {
+			if piece, ok := fenToPiece[char]; ok && x < 8 && y < 8 {
+				board.setPiece(piece, 1<<(63-(y*8+x)))
+			}
+			x++
+		}
+	}
+
+	if len(parts) >= 2 {
+		if parts[1] == "w" {
+			board.BlacksTurn = false
+		} else if parts[1] == "b" {
+			board.BlacksTurn = true
+		}
+	}
+
+	if len(parts) >= 3 {
+		for _, char := range parts[2] {
+			if char == 'Q' {
+				board.WhiteLongCastle = true
+			} else if char == 'K' {
+				board.WhiteShortCastle = true
+			} else if char == 'q' {
+				board.BlackLongCastle = true
+			} else if char == 'k' {
+				board.BlackShortCastle = true
+			}
+		}
+	}
+
+	if len(parts) >= 4 {
+		if index, ok := stringToSquare(parts[3]); ok {
+			//The en passant square is behind the pawn that just moved two steps
+			if index/8 == uint8(rank3) {
+				board.WhiteEnPassant = index % 8
+			} else if index/8 == uint8(rank6) {
+				board.BlackEnPassant = index % 8
+			}
+		}
+	}
+
+	if len(parts) >= 5 {
+		if halfmove, err := strconv.Atoi(parts[4]); err == nil {
+			board.HalfmoveClock = halfmove
+		}
+	}
+
+	if len(parts) >= 6 {
+		if fullmove, err := strconv.Atoi(parts[5]); err == nil {
+			board.FullmoveNumber = fullmove
+		}
+	}
+
+	return board
+}
+
+type FENField string
+
+const (
+	FENPlacement      FENField = "piece placement"
+	FENSideToMove     FENField = "side to move"
+	FENCastling       FENField = "castling rights"
+	FENEnPassant      FENField = "en passant square"
+	FENHalfmoveClock  FENField = "halfmove clock"
+	FENFullmoveNumber FENField = "fullmove number"
+)
+
+// FENError describes a syntax error in one field of a FEN string.
+// Char is the offending character, or 0 when the field as a whole is wrong.
+type FENError struct {
+	Field  FENField
+	Value  string
+	Char   rune
+	Reason string
+}
+
+func (err *FENError) Error() string {
+	if err.Char != 0 {
+		return fmt.Sprintf("fen: %s %q: %s %q", err.Field, err.Value, err.Reason, err.Char)
+	}
+	return fmt.Sprintf("fen: %s %q: %s", err.Field, err.Value, err.Reason)
+}
+
+var (
+	ErrMissingKing      = errors.New("position: missing king")
+	ErrTooManyKings     = errors.New("position: more than one king of the same color")
+	ErrPawnOnBackRank   = errors.New("position: pawn on first or last rank")
+	ErrOpponentInCheck  = errors.New("position: side not to move is in check")
+	ErrInvalidCastling  = errors.New("position: castling rights do not match king and rook placement")
+	ErrInvalidEnPassant = errors.New("position: en passant square does not match pawn placement")
+	ErrTooManyFENFields = errors.New("fen: too many fields")
+	ErrTooFewFENFields  = errors.New("fen: expected at least piece placement, side to move, castling rights and en passant square")
+)
+
+// ParseFEN parses a FEN string and validates the resulting position.
+// The returned board is ready to use, with its Zobrist key and repetition history set up.
+func ParseFEN(fen string) (ChessBoard, error) {
+	parts := strings.Fields(fen)
+	if len(parts) < 4 {
+		return ChessBoard{}, ErrTooFewFENFields
+	}
+	if len(parts) > 6 {
+		return ChessBoard{}, ErrTooManyFENFields
+	}
+
+	var board ChessBoard
+	board.WhiteEnPassant = 8
+	board.BlackEnPassant = 8
+	board.FullmoveNumber = 1
+
+	ranks := strings.Split(parts[0], "/")
+	if len(ranks) != 8 {
+		return ChessBoard{}, &FENError{Field: FENPlacement, Value: parts[0], Reason: fmt.Sprintf("expected 8 ranks, got %d", len(ranks))}
+	}
+	for y, rank := range ranks {
+		x := 0
+		digit := false
+		for _, char := range rank {
+			if char >= '1' && char <= '8' {
+				if digit {
+					return ChessBoard{}, &FENError{Field: FENPlacement, Value: parts[0], Char: char, Reason: fmt.Sprintf("rank %d has two digits in a row at", 8-y)}
+				}
+				x += int(char - '0')
+			} else if piece, ok := fenToPiece[char]; ok {
+				if x < 8 {
+					board.setPiece(piece, 1<<(63-(y*8+x)))
+				}
+				x++
+			} else {
+				return ChessBoard{}, &FENError{Field: FENPlacement, Value: parts[0], Char: char, Reason: "unknown piece"}
+			}
+			digit = char >= '1' && char <= '8'
+			if x > 8 {
+				return ChessBoard{}, &FENError{Field: FENPlacement, Value: parts[0], Char: char, Reason: fmt.Sprintf("rank %d has more than 8 squares at", 8-y)}
+			}
+		}
+		if x < 8 {
+			return ChessBoard{}, &FENError{Field: FENPlacement, Value: parts[0], Reason: fmt.Sprintf("rank %d has only %d squares", 8-y, x)}
+		}
+	}
+
+	switch parts[1] {
+	case "w":
+		board.BlacksTurn = false
+	case "b":
+		board.BlacksTurn = true
+	default:
+		return ChessBoard{}, &FENError{Field: FENSideToMove, Value: parts[1], Reason: "expected w or b"}
+	}
+
+	if parts[2] != "-" {
+		for _, char := range parts[2] {
+			var castle *bool
+			switch char {
+			case 'K':
+				castle = &board.WhiteShortCastle
+			case 'Q':
+				castle = &board.WhiteLongCastle
+			case 'k':
+				castle = &board.BlackShortCastle
+			case 'q':
+				castle = &board.BlackLongCastle
+			default:
+				return ChessBoard{}, &FENError{Field: FENCastling, Value: parts[2], Char: char, Reason: "unknown castling right"}
+			}
+			if *castle {
+				return ChessBoard{}, &FENError{Field: FENCastling, Value: parts[2], Char: char, Reason: "repeated castling right"}
+			}
+			*castle = true
+		}
+	}
+
+	if parts[3] != "-" {
+		index, ok := stringToSquare(parts[3])
+		if !ok {
+			return ChessBoard{}, &FENError{Field: FENEnPassant, Value: parts[3], Reason: "not a square"}
+		}
+		if board.BlacksTurn && index/8 == uint8(rank3) {
+			board.WhiteEnPassant = index % 8
+		} else if !board.BlacksTurn && index/8 == uint8(rank6) {
+			board.BlackEnPassant = index % 8
+		} else {
+			return ChessBoard{}, &FENError{Field: FENEnPassant, Value: parts[3], Reason: "square is not on the rank behind a pawn of the side that just moved"}
+		}
+	}
+
+	if len(parts) >= 5 {
+		halfmove, err := strconv.Atoi(parts[4])
+		if err != nil || halfmove < 0 {
+			return ChessBoard{}, &FENError{Field: FENHalfmoveClock, Value: parts[4], Reason: "expected a non-negative number"}
+		}
+		board.HalfmoveClock = halfmove
+	}
+
+	if len(parts) >= 6 {
+		fullmove, err := strconv.Atoi(parts[5])
+		if err != nil || fullmove < 1 {
+			return ChessBoard{}, &FENError{Field: FENFullmoveNumber, Value: parts[5], Reason: "expected a positive number"}
+		}
+		board.FullmoveNumber = fullmove
+	}
+
+	if err := board.Validate(); err != nil {
+		return ChessBoard{}, err
+	}
+	board.InitVariables()
+	return board, nil
+}
+
+// Validate checks that the position could be reached in a game closely enough for move generation and search to work on it
+func (board *ChessBoard) Validate() error {
+	if board.WhiteKing == 0 || board.BlackKing == 0 {
+		return ErrMissingKing
+	}
+	if bits.OnesCount64(uint64(board.WhiteKing)) > 1 || bits.OnesCount64(uint64(board.BlackKing)) > 1 {
+		return ErrTooManyKings
+	}
+	if (board.WhitePawns|board.BlackPawns)&(maskRank[rank1]|maskRank[rank8]) > 0 {
+		return ErrPawnOnBackRank
+	}
+	if board.CheckForCheck(board.BlacksTurn) {
+		return ErrOpponentInCheck
+	}
+
+	if (board.WhiteShortCastle || board.WhiteLongCastle) && board.WhiteKing != e1 {
+		return ErrInvalidCastling
+	}
+	if (board.WhiteShortCastle && board.WhiteRooks&h1 == 0) || (board.WhiteLongCastle && board.WhiteRooks&a1 == 0) {
+		return ErrInvalidCastling
+	}
+	if (board.BlackShortCastle || board.BlackLongCastle) && board.BlackKing != e8 {
+		return ErrInvalidCastling
+	}
+	if (board.BlackShortCastle && board.BlackRooks&h8 == 0) || (board.BlackLongCastle && board.BlackRooks&a8 == 0) {
+		return ErrInvalidCastling
+	}
+
+	if board.WhiteEnPassant < 8 {
+		if board.WhitePawns&(1<<(uint8(rank4)*8+board.WhiteEnPassant)) == 0 || board.AllPieces&(1<<(uint8(rank3)*8+board.WhiteEnPassant)|1<<(uint8(rank2)*8+board.WhiteEnPassant)) > 0 {
+			return ErrInvalidEnPassant
+		}
+	}
+	if board.BlackEnPassant < 8 {
+		if board.BlackPawns&(1<<(uint8(rank5)*8+board.BlackEnPassant)) == 0 || board.AllPieces&(1<<(uint8(rank6)*8+board.BlackEnPassant)|1<<(uint8(rank7)*8+board.BlackEnPassant)) > 0 {
+			return ErrInvalidEnPassant
+		}
+	}
+
+	return nil
+}
+
+func (board *ChessBoard) FEN() string {
+	var fen strings.Builder
+
+	for y := 0; y < 8; y++ {
+		empty := 0
+		for x := 0; x < 8; x++ {
+			piece := board.PieceOnSquare(1 << (63 - (y*8 + x)))
+			if piece == 0 {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				fen.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			fen.WriteByte(pieceToFen[piece])
+		}
+		if empty > 0 {
+			fen.WriteByte(byte('0' + empty))
+		}
+		if y < 7 {
+			fen.WriteByte('/')
+		}
+	}
+
+	if board.BlacksTurn {
+		fen.WriteString(" b ")
+	} else {
+		fen.WriteString(" w ")
+	}
+
+	castling := ""
+	if board.WhiteShortCastle {
+		castling += "K"
+	}
+	if board.WhiteLongCastle {
+		castling += "Q"
+	}
+	if board.BlackShortCastle {
+		castling += "k"
+	}
+	if board.BlackLongCastle {
+		castling += "q"
+	}
+	if castling == "" {
+		castling = "-"
+	}
+	fen.WriteString(castling)
+
+	if board.WhiteEnPassant < 8 {
+		fen.WriteString(" " + squareToString(uint8(rank3)*8+board.WhiteEnPassant))
+	} else if board.BlackEnPassant < 8 {
+		fen.WriteString(" " + squareToString(uint8(rank6)*8+board.BlackEnPassant))
+	} else {
+		fen.WriteString(" -")
+	}
+
+	fen.WriteString(" " + strconv.Itoa(board.HalfmoveClock))
+	fen.WriteString(" " + strconv.Itoa(board.FullmoveNumber))
+
+	return fen.String()
+}
+
+func squareToString(index uint8) string {
+	return posToString[1<<index]
+}
+
+func stringToSquare(square string) (uint8, bool) {
+	if len(square) != 2 || square[0] < 'a' || square[0] > 'h' || square[1] < '1' || square[1] > '8' {
+		return 0, false
+	}
+	file := square[0] - 'a'
+	rank := square[1] - '1'
+	return rank*8 + (7 - file), true
+}
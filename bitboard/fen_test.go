@@ -0,0 +1,71 @@
+package bitboard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseFENErrors(t *testing.T) {
+	const start = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR"
+	tests := []struct {
+		fen string
+		err error
+	}{
+		//Syntax errors name the field, the value and, when there is one, the character
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1", &FENError{FENPlacement, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP", 0, "expected 8 ranks, got 7"}},
+		{"rnbqkbnr/pppppppp/44/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", &FENError{FENPlacement, "rnbqkbnr/pppppppp/44/8/8/8/PPPPPPPP/RNBQKBNR", '4', "rank 6 has two digits in a row at"}},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPXPPP/RNBQKBNR w KQkq - 0 1", &FENError{FENPlacement, "rnbqkbnr/pppppppp/8/8/8/8/PPPPXPPP/RNBQKBNR", 'X', "unknown piece"}},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR0 w KQkq - 0 1", &FENError{FENPlacement, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR0", '0', "unknown piece"}},
+		{"rnbqkbnr/ppppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", &FENError{FENPlacement, "rnbqkbnr/ppppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR", 'p', "rank 7 has more than 8 squares at"}},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN w KQkq - 0 1", &FENError{FENPlacement, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN", 0, "rank 1 has only 7 squares"}},
+		{start + " x KQkq - 0 1", &FENError{FENSideToMove, "x", 0, "expected w or b"}},
+		{start + " w KQkx - 0 1", &FENError{FENCastling, "KQkx", 'x', "unknown castling right"}},
+		{start + " w KKkq - 0 1", &FENError{FENCastling, "KKkq", 'K', "repeated castling right"}},
+		{start + " w KQkq e9 0 1", &FENError{FENEnPassant, "e9", 0, "not a square"}},
+		{start + " w KQkq e3 0 1", &FENError{FENEnPassant, "e3", 0, "square is not on the rank behind a pawn of the side that just moved"}},
+		{start + " b KQkq e5 0 1", &FENError{FENEnPassant, "e5", 0, "square is not on the rank behind a pawn of the side that just moved"}},
+		{start + " w KQkq - -1 1", &FENError{FENHalfmoveClock, "-1", 0, "expected a non-negative number"}},
+		{start + " w KQkq - 0 0", &FENError{FENFullmoveNumber, "0", 0, "expected a positive number"}},
+
+		{start + " w KQkq", ErrTooFewFENFields},
+		{start + " w KQkq - 0 1 extra", ErrTooManyFENFields},
+
+		//Positions that are well formed but can not be searched
+		{"8/8/8/8/8/8/8/4K3 w - - 0 1", ErrMissingKing},
+		{"4k3/8/8/8/8/8/8/3KK3 w - - 0 1", ErrTooManyKings},
+		{"4k3/8/8/8/8/8/8/P3K3 w - - 0 1", ErrPawnOnBackRank},
+		{"4k3/4R3/8/8/8/8/8/4K3 w - - 0 1", ErrOpponentInCheck},
+		{"4k3/8/8/8/8/8/8/4K3 w K - 0 1", ErrInvalidCastling},
+		{"4k3/8/8/8/8/8/8/R3K3 w K - 0 1", ErrInvalidCastling},
+		{"4k3/8/8/8/8/8/8/3K3R w K - 0 1", ErrInvalidCastling},
+		{"4k2r/8/8/8/8/8/8/4K3 w q - 0 1", ErrInvalidCastling},
+		{"4k3/8/8/8/8/8/8/4K3 w - e6 0 1", ErrInvalidEnPassant},
+		{"4k3/8/8/4p3/8/8/8/4K3 w - d6 0 1", ErrInvalidEnPassant},
+		{"4k3/8/4n3/4p3/8/8/8/4K3 w - e6 0 1", ErrInvalidEnPassant},
+	}
+
+	for _, test := range tests {
+		_, err := ParseFEN(test.fen)
+		if want, ok := test.err.(*FENError); ok {
+			var got *FENError
+			if !errors.As(err, &got) {
+				t.Errorf("%s: got %v, want %v", test.fen, err, want)
+			} else if *got != *want {
+				t.Errorf("%s: got %#v, want %#v", test.fen, *got, *want)
+			}
+		} else if !errors.Is(err, test.err) {
+			t.Errorf("%s: got %v, want %v", test.fen, err, test.err)
+		}
+	}
+
+	for _, fen := range []string{
+		start + " w KQkq - 0 1",
+		start + " w KQkq -",
+		"4k3/8/8/4p3/8/8/8/4K3 w - e6 0 2",
+		"4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1",
+	} {
+		if _, err := ParseFEN(fen); err != nil {
+			t.Errorf("%s: %v", fen, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package bitboard
+
+import (
+	"math/bits"
+	"testing"
+)
+
+// fuzzMaxMoves keeps a single input from playing a whole game, which would make the fuzzer slow
+const fuzzMaxMoves = 256
+
+// checkBoard fails unless the incrementally updated parts of board agree with what they are computed from
+func checkBoard(t *testing.T, board *ChessBoard) {
+	t.Helper()
+	fen := board.FEN()
+
+	if hash := boardToHash(board); board.Zobrist != hash {
+		t.Fatalf("%s: Zobrist is %016x, recomputing gives %016x", fen, board.Zobrist, hash)
+	}
+
+	white := board.WhitePawns | board.WhiteRooks | board.WhiteKnights | board.WhiteBishops | board.WhiteQueens | board.WhiteKing
+	black := board.BlackPawns | board.BlackRooks | board.BlackKnights | board.BlackBishops | board.BlackQueens | board.BlackKing
+	if board.AllWhitePieces != white || board.AllBlackPieces != black || board.AllPieces != white|black {
+		t.Fatalf("%s: piece unions are out of date", fen)
+	}
+	if len(board.LastHashes) == 0 || board.LastHashes[len(board.LastHashes)-1] != board.Zobrist {
+		t.Fatalf("%s: the repetition history does not end with the position", fen)
+	}
+	if white&black != 0 {
+		t.Fatalf("%s: a square has pieces of both sides", fen)
+	}
+	if bits.OnesCount64(uint64(board.WhiteKing)) != 1 || bits.OnesCount64(uint64(board.BlackKing)) != 1 {
+		t.Fatalf("%s: a side does not have exactly one king", fen)
+	}
+
+	parsed, err := ParseFEN(fen)
+	if err != nil {
+		t.Fatalf("%s: parsing the board's own FEN: %v", fen, err)
+	}
+	if parsed.FEN() != fen {
+		t.Fatalf("%s: round-trips to %s", fen, parsed.FEN())
+	}
+	if parsed.Zobrist != board.Zobrist {
+		t.Fatalf("%s: Zobrist is %016x, after a round-trip %016x", fen, board.Zobrist, parsed.Zobrist)
+	}
+}
+
+// fuzzPositions seed both fuzzers, together with the perft positions
+var fuzzPositions = []string{
+	//The next move can be an en passant capture
+	"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+	"4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1",
+	//Pawns about to promote, with and without a capture
+	"1n2k3/P7/8/8/8/8/7p/4K1N1 w - - 0 1",
+}
+
+func FuzzParseFEN(f *testing.F) {
+	for _, position := range perftPositions {
+		f.Add(position.fen)
+	}
+	for _, fen := range fuzzPositions {
+		f.Add(fen)
+	}
+	f.Add("8/8/8/8/8/8/8/8 w - - 0 1")
+	f.Add("4k3/8/8/8/8/8/8/4K2R w KQ - 0 1")
+	f.Add("4k3/8/8/8/8/8/8/4K3 w - e6 0 1")
+
+	f.Fuzz(func(t *testing.T, fen string) {
+		board, err := ParseFEN(fen)
+		if err != nil {
+			return
+		}
+		checkBoard(t, &board)
+	})
+}
+
+// FuzzMoves plays a legal move for every byte of moves, chosen by the byte modulo the number of legal moves.
+// The invariants are checked after every move, and the copies of the board kept before each move must not be
+// changed by the moves played after them, which is how the search takes moves back.
+func FuzzMoves(f *testing.F) {
+	moves := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	for _, position := range perftPositions {
+		f.Add(position.fen, moves)
+	}
+	for _, fen := range fuzzPositions {
+		f.Add(fen, moves)
+	}
+	f.Add(perftPositions[0].fen, []byte{11, 13, 17, 5, 3, 9, 200, 31, 7, 64, 2, 19, 255, 1, 0, 128})
+
+	f.Fuzz(func(t *testing.T, fen string, moves []byte) {
+		board, err := ParseFEN(fen)
+		if err != nil {
+			return
+		}
+		if len(moves) > fuzzMaxMoves {
+			moves = moves[:fuzzMaxMoves]
+		}
+
+		var copies []ChessBoard
+		var fens []string
+		for _, b := range moves {
+			legal := board.LegalMoves()
+			if len(legal) == 0 {
+				break
+			}
+			copies = append(copies, board)
+			fens = append(fens, board.FEN())
+			board.DoMove(legal[int(b)%len(legal)])
+			checkBoard(t, &board)
+		}
+
+		for i := len(copies) - 1; i >= 0; i-- {
+			if copies[i].FEN() != fens[i] {
+				t.Fatalf("a copy of %s changed to %s", fens[i], copies[i].FEN())
+			}
+			checkBoard(t, &copies[i])
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package bitboard
+
+import "math/bits"
+
+var (
+	betweenSquares [64][64]Bitboard
+	lineThrough    [64][64]Bitboard
+)
+
+type rayFunc func(pieceLoc Bitboard, emptySpots Bitboard) Bitboard
+
+func init() {
+	directions := [8][2]rayFunc{
+		{upAttacks, downAttacks},
+		{downAttacks, upAttacks},
+		{leftAttacks, rightAttacks},
+		{rightAttacks, leftAttacks},
+		{leftUpAttacks, rightDownAttacks},
+		{rightDownAttacks, leftUpAttacks},
+		{rightUpAttacks, leftDownAttacks},
+		{leftDownAttacks, rightUpAttacks},
+	}
+	empty := ^Bitboard(0)
+	for from := 0; from < 64; from++ {
+		for _, direction := range directions {
+			ray := direction[0](1<<from, empty)
+			line := ray | direction[1](1<<from, empty) | 1<<from
+			for to := 0; to < 64; to++ {
+				if ray&(1<<to) > 0 {
+					betweenSquares[from][to] = ray & direction[1](1<<to, empty)
+					lineThrough[from][to] = line
+				}
+			}
+		}
+	}
+}
+
+type legality struct {
+	king     Bitboard
+	kingLoc  uint8
+	checkers Bitboard
+	pinned   Bitboard
+}
+
+// attackersTo returns the pieces of one side attacking square given the occupancy allPieces
+func (board *ChessBoard) attackersTo(square Bitboard, allPieces Bitboard, black bool) Bitboard {
+	index := uint8(bits.TrailingZeros64(uint64(square)))
+	rooks := RookAttacks(index, allPieces)
+	bishops := BishopAttacks(index, allPieces)
+	if black {
+		attackers := PawnAttacks(index, false) & board.BlackPawns
+		attackers |= KnightAttacks(index) & board.BlackKnights
+		attackers |= KingAttacks(index) & board.BlackKing
+		attackers |= rooks & (board.BlackRooks | board.BlackQueens)
+		attackers |= bishops & (board.BlackBishops | board.BlackQueens)
+		return attackers
+	}
+	attackers := PawnAttacks(index, true) & board.WhitePawns
+	attackers |= KnightAttacks(index) & board.WhiteKnights
+	attackers |= KingAttacks(index) & board.WhiteKing
+	attackers |= rooks & (board.WhiteRooks | board.WhiteQueens)
+	attackers |= bishops & (board.WhiteBishops | board.WhiteQueens)
+	return attackers
+}
+
+func (board *ChessBoard) legality() legality {
+	var l legality
+	ownSide, otherSide := board.AllWhitePieces, board.AllBlackPieces
+	otherRooks, otherBishops := board.BlackRooks|board.BlackQueens, board.BlackBishops|board.BlackQueens
+	l.king = board.WhiteKing
+	if board.BlacksTurn {
+		ownSide, otherSide = otherSide, ownSide
+		otherRooks, otherBishops = board.WhiteRooks|board.WhiteQueens, board.WhiteBishops|board.WhiteQueens
+		l.king = board.BlackKing
+	}
+	l.kingLoc = uint8(bits.TrailingZeros64(uint64(l.king)))
+	l.checkers = board.attackersTo(l.king, board.AllPieces, !board.BlacksTurn)
+
+	//Sliders that would attack the king if none of our pieces were in the way
+	snipers := RookAttacks(l.kingLoc, otherSide)&otherRooks | BishopAttacks(l.kingLoc, otherSide)&otherBishops
+	for _, sniper := range BitboardToSlice(snipers) {
+		blockers := betweenSquares[l.kingLoc][sniper] & board.AllPieces
+		if bits.OnesCount64(uint64(blockers)) == 1 && blockers&ownSide > 0 {
+			l.pinned |= blockers
+		}
+	}
+	return l
+}
+
+func (board *ChessBoard) isLegal(m Move, l legality) bool {
+	if m.From == l.king {
+		if m.LongCastle || m.ShortCastle {
+			return true
+		}
+		return board.attackersTo(m.To, board.AllPieces&^l.king, !board.BlacksTurn) == 0
+	}
+
+	if m.EnPassant {
+		captured := m.To >> 8
+		if board.BlacksTurn {
+			captured = m.To << 8
+		}
+		allPieces := (board.AllPieces &^ (m.From | captured)) | m.To
+		return board.attackersTo(l.king, allPieces, !board.BlacksTurn)&^captured == 0
+	}
+
+	if l.checkers > 0 {
+		if bits.OnesCount64(uint64(l.checkers)) > 1 {
+			return false
+		}
+		checker := uint8(bits.TrailingZeros64(uint64(l.checkers)))
+		if m.To&(l.checkers|betweenSquares[l.kingLoc][checker]) == 0 {
+			return false
+		}
+	}
+
+	if m.From&l.pinned > 0 && m.To&lineThrough[l.kingLoc][m.FromIndex] == 0 {
+		return false
+	}
+	return true
+}
+
+func (board *ChessBoard) LegalMoves() []Move {
+	psudomoves := board.PsudoLegalMoves(false)
+	l := board.legality()
+	moves := psudomoves[:0]
+	for _, m := range psudomoves {
+		if board.isLegal(m, l) {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// IsLegal reports whether m, matched on From, To and PawnPromotionPiece, is a legal move in the position
+func (board *ChessBoard) IsLegal(m Move) bool {
+	l := board.legality()
+	for _, legal := range board.PsudoLegalMoves(false) {
+		if legal.From == m.From && legal.To == m.To && legal.PawnPromotionPiece == m.PawnPromotionPiece {
+			return board.isLegal(legal, l)
+		}
+	}
+	return false
+}
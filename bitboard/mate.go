@@ -0,0 +1,55 @@
+package bitboard
+
+import "context"
+
+const (
+	// MateScore is the score of mating on the current move, a mate found ply half moves later scores MateScore-ply
+	MateScore int32 = 1_000_000
+
+	mateBound int32 = MateScore - 2*int32(maxSearchDepth)
+)
+
+func matedScore(ply int) int32 {
+	return -MateScore + int32(ply)
+}
+
+// mateIn converts a score to moves until mate, positive when the side to move mates and 0 for scores that are not mates
+func mateIn(score int32) int {
+	if score >= mateBound {
+		return int(MateScore-score+1) / 2
+	}
+	if score <= -mateBound {
+		return -int(MateScore+score) / 2
+	}
+	return 0
+}
+
+// scoreToTT makes mate scores relative to the stored position instead of the root, so they stay correct when the
+// position is reached at another ply. scoreFromTT converts them back.
+func scoreToTT(score int32, ply int) int32 {
+	if score >= mateBound {
+		return score + int32(ply)
+	}
+	if score <= -mateBound {
+		return score - int32(ply)
+	}
+	return score
+}
+
+func scoreFromTT(score int32, ply int) int32 {
+	if score >= mateBound {
+		return score - int32(ply)
+	}
+	if score <= -mateBound {
+		return score + int32(ply)
+	}
+	return score
+}
+
+// FindMate searches for a mate in at most moves moves for the side to move, and reports whether one was found.
+// The search stops at the first mate found, which is the shortest one at that depth.
+// Like every search with a Mate limit it runs without the pruning that could miss a forced mate.
+func (e *Engine) FindMate(ctx context.Context, board *ChessBoard, moves int, info func(SearchResult)) (SearchResult, bool) {
+	result := e.IterativeDeepening(ctx, board, Limits{Mate: moves, Infinite: true}, info)
+	return result, result.Mate > 0 && result.Mate <= moves
+}
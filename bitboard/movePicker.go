@@ -0,0 +1,162 @@
+package bitboard
+
+import "sync/atomic"
+
+type pickerStage uint8
+
+const (
+	ttMoveStage      pickerStage = 0
+	goodCaptureStage pickerStage = 1
+	killerStage      pickerStage = 2
+	counterStage     pickerStage = 3
+	quietStage       pickerStage = 4
+	badCaptureStage  pickerStage = 5
+	doneStage        pickerStage = 6
+)
+
+// movePicker hands out the moves of a position in the order they are most likely to cause a cutoff:
+// the transposition table move, good captures, killers, the countermove, quiet moves by history and bad captures.
+// Moves are given as indices into the move list, which is how the picker marks the moves it has handed out.
+// The transposition table stores a PackedMove, so the caller unpacks and validates it before passing it as ttMove.
+type movePicker struct {
+	board *ChessBoard
+	moves []Move
+	stage pickerStage
+
+	ttMove  Move
+	killers [2]Move
+	counter Move
+
+	captures    []scoredMove
+	quiets      []scoredMove
+	badCaptures []scoredMove
+	picked      []bool
+}
+
+type scoredMove struct {
+	index int
+	score int32
+}
+
+// newMovePicker makes a picker for the moves of board. ttMove is the zero Move when there is none. With onlyCaptures
+// the picker is used by the quiescence search and only hands out captures by MVV-LVA.
+func (t *thread) newMovePicker(board *ChessBoard, moves []Move, ttMove Move, ply int, onlyCaptures bool) *movePicker {
+	mp := &movePicker{board: board, moves: moves, ttMove: ttMove, picked: make([]bool, len(moves))}
+	if onlyCaptures {
+		mp.ttMove = Move{}
+		mp.stage = goodCaptureStage
+	} else {
+		mp.killers = t.killers[ply]
+		if ply > 0 {
+			if previous := t.currentMove[ply-1]; previous.From != 0 {
+				mp.counter = t.counterMoves[previous.Piece-1][previous.ToIndex]
+			}
+		}
+	}
+
+	side := sideIndex(board.BlacksTurn)
+	for i, m := range moves {
+		if isQuiet(board, m) {
+			if !onlyCaptures {
+				mp.quiets = append(mp.quiets, scoredMove{i, atomic.LoadInt32(&t.engine.history[side][m.FromIndex][m.ToIndex])})
+			}
+			continue
+		}
+		score := mvvLva(board, m)
+		if onlyCaptures || board.SEEGreaterOrEqual(m, 0) {
+			mp.captures = append(mp.captures, scoredMove{i, score})
+		} else {
+			mp.badCaptures = append(mp.badCaptures, scoredMove{i, score})
+		}
+	}
+	return mp
+}
+
+// mvvLva scores captures by the most valuable victim first, and among those by the least valuable attacker
+func mvvLva(board *ChessBoard, m Move) int32 {
+	victim := pieceValue(board.PieceOnSquare(m.To))
+	if m.EnPassant {
+		victim = pieceValue(WhitePawn)
+	}
+	if m.PawnPromotionPiece != 0 {
+		victim += pieceValue(m.PawnPromotionPiece)
+	}
+	return victim*16 - pieceValue(m.Piece)/16
+}
+
+// next returns the index of the next move, and false when all moves have been handed out
+func (mp *movePicker) next() (int, bool) {
+	for {
+		switch mp.stage {
+		case ttMoveStage:
+			mp.stage = goodCaptureStage
+			if mp.ttMove.From != 0 {
+				for i, m := range mp.moves {
+					if m == mp.ttMove {
+						return mp.take(i)
+					}
+				}
+			}
+		case goodCaptureStage:
+			if i, ok := mp.best(mp.captures); ok {
+				return mp.take(i)
+			}
+			mp.stage = killerStage
+		case killerStage:
+			mp.stage = counterStage
+			for _, killer := range mp.killers {
+				if i, ok := mp.find(killer); ok {
+					return mp.take(i)
+				}
+			}
+		case counterStage:
+			mp.stage = quietStage
+			if i, ok := mp.find(mp.counter); ok {
+				return mp.take(i)
+			}
+		case quietStage:
+			if i, ok := mp.best(mp.quiets); ok {
+				return mp.take(i)
+			}
+			mp.stage = badCaptureStage
+		case badCaptureStage:
+			if i, ok := mp.best(mp.badCaptures); ok {
+				return mp.take(i)
+			}
+			mp.stage = doneStage
+		default:
+			return 0, false
+		}
+	}
+}
+
+func (mp *movePicker) take(i int) (int, bool) {
+	mp.picked[i] = true
+	return i, true
+}
+
+// best finds the highest scored move not yet handed out. Selecting one at a time is cheaper than sorting,
+// since most nodes cut off after a few moves.
+func (mp *movePicker) best(moves []scoredMove) (int, bool) {
+	bestIndex := -1
+	bestScore := int32lowest
+	for _, sm := range moves {
+		if !mp.picked[sm.index] && (bestIndex == -1 || sm.score > bestScore) {
+			bestIndex, bestScore = sm.index, sm.score
+		}
+	}
+	return bestIndex, bestIndex != -1
+}
+
+// find looks for a quiet move that has not been handed out yet
+func (mp *movePicker) find(m Move) (int, bool) {
+	if m.From == 0 {
+		return 0, false
+	}
+	for i, other := range mp.moves {
+		if other == m && !mp.picked[i] && isQuiet(mp.board, other) {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,118 @@
+package bitboard
+
+import "math/bits"
+
+type Result uint8
+
+type Termination uint8
+
+const (
+	Ongoing   Result = 0
+	WhiteWins Result = 1
+	BlackWins Result = 2
+	Draw      Result = 3
+
+	Checkmate            Termination = 1
+	Stalemate            Termination = 2
+	FivefoldRepetition   Termination = 3
+	SeventyFiveMoveRule  Termination = 4
+	InsufficientMaterial Termination = 5
+	ThreefoldRepetition  Termination = 6
+	FiftyMoveRule        Termination = 7
+)
+
+const darkSquares Bitboard = 0x55aa55aa55aa55aa
+
+type Outcome struct {
+	Result      Result
+	Termination Termination
+}
+
+func (result Result) String() string {
+	switch result {
+	case WhiteWins:
+		return "1-0"
+	case BlackWins:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	}
+	return "*"
+}
+
+func (termination Termination) String() string {
+	switch termination {
+	case Checkmate:
+		return "checkmate"
+	case Stalemate:
+		return "stalemate"
+	case FivefoldRepetition:
+		return "fivefold repetition"
+	case SeventyFiveMoveRule:
+		return "75-move rule"
+	case InsufficientMaterial:
+		return "insufficient material"
+	case ThreefoldRepetition:
+		return "threefold repetition"
+	case FiftyMoveRule:
+		return "50-move rule"
+	}
+	return ""
+}
+
+// Outcome reports whether the game is over. Threefold repetition and the 50-move rule
+// can only be claimed over the board, but are reported as draws like most engines and servers do.
+func (board *ChessBoard) Outcome() Outcome {
+	if len(board.LegalMoves()) == 0 {
+		if !board.CheckForCheck(!board.BlacksTurn) {
+			return Outcome{Draw, Stalemate}
+		}
+		if board.BlacksTurn {
+			return Outcome{WhiteWins, Checkmate}
+		}
+		return Outcome{BlackWins, Checkmate}
+	}
+
+	repetitions := board.Repetitions()
+	if repetitions >= 5 {
+		return Outcome{Draw, FivefoldRepetition}
+	}
+	if board.HalfmoveClock >= 150 {
+		return Outcome{Draw, SeventyFiveMoveRule}
+	}
+	if board.InsufficientMaterial() {
+		return Outcome{Draw, InsufficientMaterial}
+	}
+	if repetitions >= 3 {
+		return Outcome{Draw, ThreefoldRepetition}
+	}
+	if board.HalfmoveClock >= 100 {
+		return Outcome{Draw, FiftyMoveRule}
+	}
+	return Outcome{}
+}
+
+// Repetitions counts how many times the current position has occurred since the last irreversible move
+func (board *ChessBoard) Repetitions() int {
+	repetitions := 0
+	for _, hash := range board.LastHashes {
+		if hash == board.Zobrist {
+			repetitions++
+		}
+	}
+	return repetitions
+}
+
+// InsufficientMaterial reports whether neither side can possibly checkmate:
+// only kings, a single minor piece, or bishops that are all on the same square color
+func (board *ChessBoard) InsufficientMaterial() bool {
+	if board.WhitePawns|board.BlackPawns|board.WhiteRooks|board.BlackRooks|board.WhiteQueens|board.BlackQueens > 0 {
+		return false
+	}
+	knights := board.WhiteKnights | board.BlackKnights
+	bishops := board.WhiteBishops | board.BlackBishops
+	if bits.OnesCount64(uint64(knights|bishops)) <= 1 {
+		return true
+	}
+	return knights == 0 && (bishops&darkSquares == 0 || bishops&^darkSquares == 0)
+}
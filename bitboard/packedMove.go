@@ -0,0 +1,155 @@
+package bitboard
+
+// PackedMove stores a move in 16 bits: the from square in bits 0-5, the to square in bits 6-11,
+// the promotion piece in bits 12-13 and the kind of move in bits 14-15. The zero value is no move.
+// Unlike an index into a move list, it means the same move no matter how the moves are generated.
+type PackedMove uint16
+
+const (
+	normalMove    PackedMove = 0 << 14
+	promotionMove PackedMove = 1 << 14
+	enPassantMove PackedMove = 2 << 14
+	castleMove    PackedMove = 3 << 14
+
+	moveKindMask PackedMove = 3 << 14
+)
+
+// packedPromotions are the white promotion pieces in the order they are numbered in a PackedMove
+var packedPromotions [4]PieceType = [4]PieceType{WhiteKnight, WhiteBishop, WhiteRook, WhiteQueen}
+
+func (m Move) Pack() PackedMove {
+	if m.From == 0 {
+		return 0
+	}
+	packed := PackedMove(m.FromIndex) | PackedMove(m.ToIndex)<<6
+	switch {
+	case m.LongCastle || m.ShortCastle:
+		packed |= castleMove
+	case m.EnPassant:
+		packed |= enPassantMove
+	case m.PawnPromotionPiece != 0:
+		packed |= promotionMove
+		for i, piece := range packedPromotions {
+			if piece == whitePiece(m.PawnPromotionPiece) {
+				packed |= PackedMove(i) << 12
+			}
+		}
+	}
+	return packed
+}
+
+// UnpackMove turns a packed move back into a move on board, filling in the moving piece.
+// The result is only a move of the position if IsPseudoLegal says so.
+func (board *ChessBoard) UnpackMove(packed PackedMove) Move {
+	if packed == 0 {
+		return Move{}
+	}
+	fromIndex := uint8(packed & 63)
+	toIndex := uint8(packed >> 6 & 63)
+	m := Move{From: 1 << fromIndex, To: 1 << toIndex, FromIndex: fromIndex, ToIndex: toIndex}
+	m.Piece = board.PieceOnSquare(m.From)
+
+	switch packed & moveKindMask {
+	case castleMove:
+		m.LongCastle = m.To&(c1|c8) > 0
+		m.ShortCastle = m.To&(g1|g8) > 0
+	case enPassantMove:
+		m.EnPassant = true
+	case promotionMove:
+		m.PawnPromotionPiece = packedPromotions[packed>>12&3]
+		if m.Piece == BlackPawn {
+			m.PawnPromotionPiece += BlackPawn - WhitePawn
+		}
+	}
+	return m
+}
+
+// IsPseudoLegal reports whether m is one of the moves PsudoLegalMoves would return, without generating them.
+// It is used to check moves from the transposition table, which may come from another position.
+func (board *ChessBoard) IsPseudoLegal(m Move) bool {
+	if m.From == 0 || m.From != 1<<m.FromIndex || m.To != 1<<m.ToIndex {
+		return false
+	}
+	if m.Piece == 0 || board.PieceOnSquare(m.From) != m.Piece || (m.Piece >= BlackPawn) != board.BlacksTurn {
+		return false
+	}
+	ownSide, otherSide := board.AllWhitePieces, board.AllBlackPieces
+	if board.BlacksTurn {
+		ownSide, otherSide = otherSide, ownSide
+	}
+
+	if m.LongCastle || m.ShortCastle {
+		return board.isPseudoLegalCastle(m)
+	}
+
+	piece := whitePiece(m.Piece)
+	if piece != WhitePawn {
+		if m.EnPassant || m.PawnPromotionPiece != 0 {
+			return false
+		}
+	} else {
+		lastRank := maskRank[rank8]
+		if board.BlacksTurn {
+			lastRank = maskRank[rank1]
+		}
+		promotion := whitePiece(m.PawnPromotionPiece)
+		if (m.To&lastRank > 0) != (promotion != 0) {
+			return false
+		}
+		if promotion != 0 && (promotion == WhitePawn || promotion == WhiteKing || (m.PawnPromotionPiece >= BlackPawn) != board.BlacksTurn) {
+			return false
+		}
+	}
+
+	var moves Bitboard
+	switch piece {
+	case WhitePawn:
+		if m.EnPassant {
+			var left, right Bitboard
+			if board.BlacksTurn {
+				left, right = blackEnPassant(m.From, board.WhiteEnPassant)
+			} else {
+				left, right = whiteEnPassant(m.From, board.BlackEnPassant)
+			}
+			return (left|right)&m.To > 0
+		}
+		if board.BlacksTurn {
+			moves = blackPawnMoves(m.From, board.AllPieces, otherSide)
+		} else {
+			moves = whitePawnMoves(m.From, board.AllPieces, otherSide)
+		}
+	case WhiteRook:
+		moves = rookMoves(m.From, board.AllPieces, ownSide)
+	case WhiteKnight:
+		moves = knightMoves(m.From, ownSide)
+	case WhiteBishop:
+		moves = bishopMoves(m.From, board.AllPieces, ownSide)
+	case WhiteQueen:
+		moves = queenMoves(m.From, board.AllPieces, ownSide)
+	case WhiteKing:
+		moves = kingMoves(m.From, ownSide)
+	}
+	return moves&m.To > 0
+}
+
+func (board *ChessBoard) isPseudoLegalCastle(m Move) bool {
+	if m.LongCastle == m.ShortCastle || m.EnPassant || m.PawnPromotionPiece != 0 {
+		return false
+	}
+	if board.BlacksTurn {
+		if m.Piece != BlackKing || m.From != board.BlackKing {
+			return false
+		}
+		if m.LongCastle {
+			return m.To == c8 && blackLongCastle(board.BlackLongCastle, board.AllPieces, board.WhiteAttacking())
+		}
+		return m.To == g8 && blackShortCastle(board.BlackShortCastle, board.AllPieces, board.WhiteAttacking())
+	}
+	if m.Piece != WhiteKing || m.From != board.WhiteKing {
+		return false
+	}
+	if m.LongCastle {
+		return m.To == c1 && whiteLongCastle(board.WhiteLongCastle, board.AllPieces, board.BlackAttacking())
+	}
+	return m.To == g1 && whiteShortCastle(board.WhiteShortCastle, board.AllPieces, board.BlackAttacking())
+}
@@ -0,0 +1,165 @@
+package bitboard
+
+import (
+	"math/bits"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// MoveCount is the number of leaf nodes below one root move, as returned by Divide
+type MoveCount struct {
+	Move  Move
+	Nodes uint64
+}
+
+// Perft counts the positions reached by playing every sequence of depth legal moves from board.
+// The counts of well known positions are published, so they show whether move generation is correct.
+func Perft(board *ChessBoard, depth int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	moves := board.LegalMoves()
+	//Every legal move leads to exactly one position, so the last ply needs no moves made
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	nodes := uint64(0)
+	for _, m := range moves {
+		temp := *board
+		board.DoMove(m)
+		nodes += Perft(board, depth-1)
+		*board = temp
+	}
+	return nodes
+}
+
+// Divide splits the perft count by root move, sorted by the moves in UCI notation.
+// Comparing it with another engine points out the move below which the counts differ.
+func Divide(board *ChessBoard, depth int) []MoveCount {
+	if depth < 1 {
+		return nil
+	}
+	var counts []MoveCount
+	for _, m := range board.LegalMoves() {
+		temp := *board
+		board.DoMove(m)
+		counts = append(counts, MoveCount{Move: m, Nodes: Perft(board, depth-1)})
+		*board = temp
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Move.UCI() < counts[j].Move.UCI()
+	})
+	return counts
+}
+
+// perftTable caches the counts of subtrees by position and depth. It is shared by the goroutines of
+// ParallelDivide, and like the transposition table it ignores entries that were torn by two writes at once.
+type perftTable struct {
+	entries []Entry
+}
+
+func newPerftTable(megabytes int) *perftTable {
+	size := megabytes * 1024 * 1024 / int(unsafe.Sizeof(Entry{}))
+	if size < 1 {
+		return nil
+	}
+	return &perftTable{entries: make([]Entry, size)}
+}
+
+func (pt *perftTable) entry(zobrist uint64) *Entry {
+	index, _ := bits.Mul64(zobrist, uint64(len(pt.entries)))
+	return &pt.entries[index]
+}
+
+func (pt *perftTable) get(zobrist uint64, depth int) (uint64, bool) {
+	entry := pt.entry(zobrist)
+	data := atomic.LoadUint64(&entry.Data)
+	key := atomic.LoadUint64(&entry.Zobrist)
+	if key^data != zobrist || int(data&Mask8bit) != depth {
+		return 0, false
+	}
+	return data >> 8, true
+}
+
+func (pt *perftTable) store(zobrist uint64, depth int, nodes uint64) {
+	data := nodes<<8 | uint64(depth)
+	entry := pt.entry(zobrist)
+	atomic.StoreUint64(&entry.Zobrist, zobrist^data)
+	atomic.StoreUint64(&entry.Data, data)
+}
+
+// hashPerft counts like Perft, looking up and saving the counts of subtrees in table
+func hashPerft(board *ChessBoard, depth int, table *perftTable) uint64 {
+	if depth <= 1 || table == nil {
+		return Perft(board, depth)
+	}
+	if nodes, ok := table.get(board.Zobrist, depth); ok {
+		return nodes
+	}
+	nodes := uint64(0)
+	for _, m := range board.LegalMoves() {
+		temp := *board
+		board.DoMove(m)
+		nodes += hashPerft(board, depth-1, table)
+		*board = temp
+	}
+	table.store(board.Zobrist, depth, nodes)
+	return nodes
+}
+
+// ParallelDivide returns the same counts as Divide, but searches the root moves in threads goroutines,
+// and shares the counts of positions reached more than once in a table of hashMegabytes. Without a table
+// nothing is cached. Counts of up to 2^56 nodes are cached, which is more than any practical depth reaches.
+func ParallelDivide(board *ChessBoard, depth int, threads int, hashMegabytes int) []MoveCount {
+	if depth < 1 {
+		return nil
+	}
+	if threads < 1 {
+		threads = 1
+	}
+	table := newPerftTable(hashMegabytes)
+	moves := board.LegalMoves()
+	counts := make([]MoveCount, len(moves))
+
+	var next int32 = -1
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b := *board
+			b.LastHashes = append([]uint64(nil), board.LastHashes...)
+			for {
+				index := int(atomic.AddInt32(&next, 1))
+				if index >= len(moves) {
+					return
+				}
+				m := moves[index]
+				temp := b
+				b.DoMove(m)
+				counts[index] = MoveCount{Move: m, Nodes: hashPerft(&b, depth-1, table)}
+				b = temp
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Move.UCI() < counts[j].Move.UCI()
+	})
+	return counts
+}
+
+// ParallelPerft counts like Perft, using ParallelDivide
+func ParallelPerft(board *ChessBoard, depth int, threads int, hashMegabytes int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	nodes := uint64(0)
+	for _, count := range ParallelDivide(board, depth, threads, hashMegabytes) {
+		nodes += count.Nodes
+	}
+	return nodes
+}
@@ -0,0 +1,120 @@
+package bitboard
+
+import (
+	"runtime"
+	"testing"
+)
+
+// perftPositions are the standard test positions, with their published node counts by depth starting at 1
+var perftPositions = []struct {
+	name  string
+	fen   string
+	nodes []uint64
+}{
+	{"Start", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", []uint64{20, 400, 8902, 197281, 4865609}},
+	{"Kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", []uint64{48, 2039, 97862, 4085603}},
+	{"Position3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []uint64{14, 191, 2812, 43238, 674624}},
+	{"Position4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []uint64{6, 264, 9467, 422333}},
+	{"Position4Mirrored", "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1", []uint64{6, 264, 9467, 422333}},
+	{"Position5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", []uint64{44, 1486, 62379, 2103487}},
+	{"Position6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", []uint64{46, 2079, 89890, 3894594}},
+}
+
+// perftShortLimit is the largest count checked with -short
+const perftShortLimit uint64 = 100000
+
+func TestPerft(t *testing.T) {
+	for _, position := range perftPositions {
+		t.Run(position.name, func(t *testing.T) {
+			board, err := ParseFEN(position.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fen := board.FEN()
+			for i, want := range position.nodes {
+				if testing.Short() && want > perftShortLimit {
+					break
+				}
+				if got := Perft(&board, i+1); got != want {
+					t.Errorf("depth %d: got %d nodes, want %d", i+1, got, want)
+				}
+			}
+			if board.FEN() != fen {
+				t.Errorf("board changed to %s", board.FEN())
+			}
+		})
+	}
+}
+
+func TestDivide(t *testing.T) {
+	for _, position := range perftPositions {
+		board, err := ParseFEN(position.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := Divide(&board, 3)
+		if len(counts) != int(position.nodes[0]) {
+			t.Errorf("%s: %d root moves, want %d", position.name, len(counts), position.nodes[0])
+		}
+		total := uint64(0)
+		for i, count := range counts {
+			total += count.Nodes
+			if i > 0 && counts[i-1].Move.UCI() >= count.Move.UCI() {
+				t.Errorf("%s: %s is sorted before %s", position.name, counts[i-1].Move.UCI(), count.Move.UCI())
+			}
+		}
+		if total != position.nodes[2] {
+			t.Errorf("%s: divide adds up to %d, want %d", position.name, total, position.nodes[2])
+		}
+	}
+}
+
+func TestParallelPerft(t *testing.T) {
+	for _, position := range perftPositions {
+		t.Run(position.name, func(t *testing.T) {
+			board, err := ParseFEN(position.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range position.nodes {
+				if testing.Short() && want > perftShortLimit {
+					break
+				}
+				//A tiny table makes positions overwrite each other all the time
+				for _, hash := range []int{0, 1, 16} {
+					if got := ParallelPerft(&board, i+1, 3, hash); got != want {
+						t.Errorf("depth %d with %d MB: got %d nodes, want %d", i+1, hash, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	for _, position := range benchmarkPositions {
+		board, err := ParseFEN(position.fen)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(position.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Perft(&board, 4)
+			}
+		})
+	}
+}
+
+func BenchmarkParallelPerft(b *testing.B) {
+	for _, position := range benchmarkPositions {
+		board, err := ParseFEN(position.fen)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(position.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ParallelPerft(&board, 5, runtime.NumCPU(), 64)
+			}
+		})
+	}
+}
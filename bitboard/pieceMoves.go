@@ -1,5 +1,7 @@
 package bitboard
 
+import "math/bits"
+
 func kingMoves(kingLoc Bitboard, ownSide Bitboard) Bitboard {
 	kingClearFileA := kingLoc & clearFile[fileA]
 	kingClearFileH := kingLoc & clearFile[fileH]
@@ -228,31 +230,40 @@ func leftDownAttacks(pieceLoc Bitboard, emptySpots Bitboard) Bitboard {
 }
 
 func bishopMoves(bishopLoc Bitboard, allPieces Bitboard, ownSide Bitboard) Bitboard {
-	rightUp := rightUpAttacks(bishopLoc, ^allPieces)
-	rightDown := rightDownAttacks(bishopLoc, ^allPieces)
-	leftUp := leftUpAttacks(bishopLoc, ^allPieces)
-	leftDown := leftDownAttacks(bishopLoc, ^allPieces)
-	return (rightUp | rightDown | leftUp | leftDown) & ^ownSide
+	var moves Bitboard
+	for ; bishopLoc > 0; bishopLoc &= bishopLoc - 1 {
+		moves |= BishopAttacks(uint8(bits.TrailingZeros64(uint64(bishopLoc))), allPieces)
+	}
+	return moves & ^ownSide
 }
 
 func rookMoves(rookLoc Bitboard, allPieces Bitboard, ownSide Bitboard) Bitboard {
+	var moves Bitboard
+	for ; rookLoc > 0; rookLoc &= rookLoc - 1 {
+		moves |= RookAttacks(uint8(bits.TrailingZeros64(uint64(rookLoc))), allPieces)
+	}
+	return moves & ^ownSide
+}
+
+func queenMoves(queenLoc Bitboard, allPieces Bitboard, ownSide Bitboard) Bitboard {
+	return (rookMoves(queenLoc, allPieces, 0) | bishopMoves(queenLoc, allPieces, 0)) & ^ownSide
+}
+
+// slidingRookAttacks floods the rays of the rooks in rookLoc one step at a time. It is only used to fill the attack tables.
+func slidingRookAttacks(rookLoc Bitboard, allPieces Bitboard) Bitboard {
 	up := upAttacks(rookLoc, ^allPieces)
 	right := rightAttacks(rookLoc, ^allPieces)
 	down := downAttacks(rookLoc, ^allPieces)
 	left := leftAttacks(rookLoc, ^allPieces)
-	return (up | right | down | left) & ^ownSide
+	return up | right | down | left
 }
 
-func queenMoves(queenLoc Bitboard, allPieces Bitboard, ownSide Bitboard) Bitboard {
-	up := upAttacks(queenLoc, ^allPieces)
-	right := rightAttacks(queenLoc, ^allPieces)
-	down := downAttacks(queenLoc, ^allPieces)
-	left := leftAttacks(queenLoc, ^allPieces)
-	rightUp := rightUpAttacks(queenLoc, ^allPieces)
-	rightDown := rightDownAttacks(queenLoc, ^allPieces)
-	leftUp := leftUpAttacks(queenLoc, ^allPieces)
-	leftDown := leftDownAttacks(queenLoc, ^allPieces)
-	return (up | right | down | left | rightUp | rightDown | leftUp | leftDown) & ^ownSide
+func slidingBishopAttacks(bishopLoc Bitboard, allPieces Bitboard) Bitboard {
+	rightUp := rightUpAttacks(bishopLoc, ^allPieces)
+	rightDown := rightDownAttacks(bishopLoc, ^allPieces)
+	leftUp := leftUpAttacks(bishopLoc, ^allPieces)
+	leftDown := leftDownAttacks(bishopLoc, ^allPieces)
+	return rightUp | rightDown | leftUp | leftDown
 }
 
 func whiteLongCastle(castleValid bool, allPieces Bitboard, blackAttacking Bitboard) bool {
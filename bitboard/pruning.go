@@ -0,0 +1,57 @@
+package bitboard
+
+import "math"
+
+const (
+	aspirationWindow      int32 = 25
+	aspirationFullWindow  int32 = 1000
+	reverseFutilityDepth  uint8 = 6
+	reverseFutilityMargin int32 = 80
+	futilityDepth         uint8 = 3
+	nullMoveDepth         uint8 = 3
+	nullMoveVerifyDepth   uint8 = 10
+	lmrDepth              uint8 = 3
+	lmrMoves              int   = 3
+	deltaMargin           int32 = 200
+)
+
+var futilityMargins [futilityDepth + 1]int32 = [futilityDepth + 1]int32{0, 150, 300, 500}
+
+// lmrReductions[depth][moves] is how much a late quiet move is reduced
+var lmrReductions [maxSearchDepth + 1][64]uint8
+
+func init() {
+	for depth := 1; depth <= maxSearchDepth; depth++ {
+		for moves := 1; moves < 64; moves++ {
+			lmrReductions[depth][moves] = uint8(0.75 + math.Log(float64(depth))*math.Log(float64(moves))/2.25)
+		}
+	}
+}
+
+func lateMoveReduction(depth uint8, moves int) uint8 {
+	if moves > 63 {
+		moves = 63
+	}
+	reduction := lmrReductions[depth][moves]
+	//Always leave at least one ply before the quiescence search
+	if reduction > depth-2 {
+		reduction = depth - 2
+	}
+	return reduction
+}
+
+// hasNonPawnMaterial guards null move pruning against zugzwang, which is common in pawn endgames
+func (board *ChessBoard) hasNonPawnMaterial() bool {
+	if board.BlacksTurn {
+		return board.BlackRooks|board.BlackKnights|board.BlackBishops|board.BlackQueens > 0
+	}
+	return board.WhiteRooks|board.WhiteKnights|board.WhiteBishops|board.WhiteQueens > 0
+}
+
+// pieceValue is the middlegame material value of a piece, 0 for an empty square
+func pieceValue(piece PieceType) int32 {
+	if piece == 0 {
+		return 0
+	}
+	return mgValues[whitePiece(piece)-1]
+}
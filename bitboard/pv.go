@@ -0,0 +1,69 @@
+package bitboard
+
+import "time"
+
+// SearchResult is the outcome of a search, and is also reported after every completed depth.
+// Score is in centipawns from the view of the side to move. Mate is the number of moves until mate
+// when the score is a mate score, negative if the side to move is getting mated, and otherwise 0.
+// MultiPV is the rank of the line, starting at 1 for the best.
+type SearchResult struct {
+	MultiPV    int
+	BestMove   Move
+	PonderMove Move
+	Score      int32
+	Mate       int
+	Depth      int
+	SelDepth   int
+	Nodes      uint64
+	Hashfull   int // permille of the transposition table used by this search
+	Time       time.Duration
+	PV         []Move
+}
+
+func (e *Engine) result(t *thread, line int, board *ChessBoard, start time.Time) SearchResult {
+	result := SearchResult{
+		MultiPV:  line + 1,
+		Score:    t.lines[line].score,
+		Depth:    t.depth,
+		SelDepth: t.selDepth,
+		Nodes:    e.Stats().Nodes,
+		Hashfull: e.Hashfull(),
+		Time:     time.Since(start),
+	}
+	if len(t.lines[line].pv) == 0 {
+		//Stopped before the first move was searched
+		result.BestMove = t.rootMoves[line]
+		result.PV = []Move{result.BestMove}
+		return result
+	}
+
+	result.PV = e.extendPV(board, append([]Move(nil), t.lines[line].pv...))
+	result.BestMove = result.PV[0]
+	if len(result.PV) > 1 {
+		result.PonderMove = result.PV[1]
+	}
+	result.Mate = mateIn(result.Score)
+	return result
+}
+
+// extendPV follows exact transposition table entries after the end of pv, which is cut short where the search used the table
+func (e *Engine) extendPV(board *ChessBoard, pv []Move) []Move {
+	b := *board
+	b.LastHashes = append([]uint64(nil), board.LastHashes...)
+	for _, m := range pv {
+		b.DoMove(m)
+	}
+	for len(pv) < maxSearchDepth && b.Repetitions() < 2 {
+		packed, _, _, node, _, matching := e.tt.Get(b.Zobrist)
+		if !matching || node != ExactNode {
+			break
+		}
+		m := b.UnpackMove(packed)
+		if !b.IsPseudoLegal(m) || !b.IsLegal(m) {
+			break
+		}
+		pv = append(pv, m)
+		b.DoMove(m)
+	}
+	return pv
+}
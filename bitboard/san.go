@@ -0,0 +1,169 @@
+package bitboard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidSAN    = errors.New("invalid SAN")
+	ErrIllegalMove   = errors.New("illegal move")
+	ErrAmbiguousMove = errors.New("ambiguous move")
+)
+
+var sanToPiece map[byte]PieceType = map[byte]PieceType{
+	'R': WhiteRook, 'N': WhiteKnight, 'B': WhiteBishop, 'Q': WhiteQueen, 'K': WhiteKing,
+}
+
+// whitePiece maps black piece types to the white piece of the same kind
+func whitePiece(piece PieceType) PieceType {
+	if piece > WhiteKing {
+		return piece - 6
+	}
+	return piece
+}
+
+func (board *ChessBoard) MoveToSAN(m Move) string {
+	var san strings.Builder
+
+	if m.ShortCastle {
+		san.WriteString("O-O")
+	} else if m.LongCastle {
+		san.WriteString("O-O-O")
+	} else {
+		piece := whitePiece(m.Piece)
+		capture := board.AllPieces&m.To > 0 || m.EnPassant
+		if piece == WhitePawn {
+			if capture {
+				san.WriteByte(squareToString(m.FromIndex)[0])
+			}
+		} else {
+			san.WriteByte(pieceToFen[piece])
+
+			sameFile, sameRank, ambiguous := false, false, false
+			for _, other := range board.LegalMoves() {
+				if other.Piece != m.Piece || other.To != m.To || other.From == m.From {
+					continue
+				}
+				ambiguous = true
+				if other.FromIndex%8 == m.FromIndex%8 {
+					sameFile = true
+				}
+				if other.FromIndex/8 == m.FromIndex/8 {
+					sameRank = true
+				}
+			}
+			from := squareToString(m.FromIndex)
+			if ambiguous {
+				if !sameFile {
+					san.WriteByte(from[0])
+				} else if !sameRank {
+					san.WriteByte(from[1])
+				} else {
+					san.WriteString(from)
+				}
+			}
+		}
+		if capture {
+			san.WriteByte('x')
+		}
+		san.WriteString(squareToString(m.ToIndex))
+		if m.PawnPromotionPiece != 0 {
+			san.WriteByte('=')
+			san.WriteByte(pieceToFen[whitePiece(m.PawnPromotionPiece)])
+		}
+	}
+
+	after := *board
+	after.DoMove(m)
+	if after.CheckForCheck(!after.BlacksTurn) {
+		if len(after.LegalMoves()) == 0 {
+			san.WriteByte('#')
+		} else {
+			san.WriteByte('+')
+		}
+	}
+	return san.String()
+}
+
+// ParseSAN finds the legal move written in Standard Algebraic Notation.
+// Check, mate and annotation suffixes are ignored, and both O-O and 0-0 are accepted for castling.
+func (board *ChessBoard) ParseSAN(san string) (Move, error) {
+	text := strings.TrimRight(strings.TrimSpace(san), "+#!?")
+
+	if text == "O-O" || text == "0-0" || text == "O-O-O" || text == "0-0-0" {
+		long := len(text) == 5
+		for _, m := range board.LegalMoves() {
+			if (long && m.LongCastle) || (!long && m.ShortCastle) {
+				return m, nil
+			}
+		}
+		return Move{}, fmt.Errorf("san %q: %w", san, ErrIllegalMove)
+	}
+
+	piece := WhitePawn
+	if len(text) > 0 {
+		if p, ok := sanToPiece[text[0]]; ok {
+			piece = p
+			text = text[1:]
+		}
+	}
+
+	var promotion PieceType
+	if i := strings.IndexByte(text, '='); i >= 0 {
+		p, ok := sanToPiece[text[len(text)-1]]
+		if i != len(text)-2 || !ok || p == WhiteKing {
+			return Move{}, fmt.Errorf("san %q: %w", san, ErrInvalidSAN)
+		}
+		promotion = p
+		text = text[:i]
+	} else if len(text) > 0 && piece == WhitePawn {
+		if p, ok := sanToPiece[text[len(text)-1]]; ok && p != WhiteKing {
+			promotion = p
+			text = text[:len(text)-1]
+		}
+	}
+
+	if len(text) < 2 {
+		return Move{}, fmt.Errorf("san %q: %w", san, ErrInvalidSAN)
+	}
+	to, ok := stringToSquare(text[len(text)-2:])
+	if !ok {
+		return Move{}, fmt.Errorf("san %q: %w", san, ErrInvalidSAN)
+	}
+	text = strings.NewReplacer("x", "", "-", "").Replace(text[:len(text)-2])
+
+	fromFile, fromRank := -1, -1
+	for _, char := range text {
+		if char >= 'a' && char <= 'h' && fromFile == -1 {
+			fromFile = int(7 - (char - 'a'))
+		} else if char >= '1' && char <= '8' && fromRank == -1 {
+			fromRank = int(char - '1')
+		} else {
+			return Move{}, fmt.Errorf("san %q: %w", san, ErrInvalidSAN)
+		}
+	}
+
+	var found []Move
+	for _, m := range board.LegalMoves() {
+		if whitePiece(m.Piece) != piece || m.ToIndex != to || whitePiece(m.PawnPromotionPiece) != promotion {
+			continue
+		}
+		if m.LongCastle || m.ShortCastle {
+			continue
+		}
+		if (fromFile != -1 && int(m.FromIndex%8) != fromFile) || (fromRank != -1 && int(m.FromIndex/8) != fromRank) {
+			continue
+		}
+		found = append(found, m)
+	}
+
+	if len(found) == 0 {
+		return Move{}, fmt.Errorf("san %q: %w", san, ErrIllegalMove)
+	}
+	if len(found) > 1 {
+		return Move{}, fmt.Errorf("san %q: %w", san, ErrAmbiguousMove)
+	}
+	return found[0], nil
+}
@@ -0,0 +1,76 @@
+package bitboard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		fen, uci, san string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", "e4"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "g1f3", "Nf3"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6", "exf6"},
+		//Two rooks on the same rank, two on the same file, and queens that need both
+		{"2k5/8/8/8/8/8/8/R4RK1 w - - 0 1", "a1d1", "Rad1"},
+		{"2k5/8/8/8/8/8/8/R4RK1 w - - 0 1", "f1d1", "Rfd1"},
+		{"2k5/8/8/R7/8/8/8/R3K3 w - - 0 1", "a1a3", "R1a3"},
+		{"2k5/8/8/R7/8/8/8/R3K3 w - - 0 1", "a5a3", "R5a3"},
+		{"8/7k/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "a1b2", "Qa1b2"},
+		{"8/7k/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "a3b2", "Q3b2"},
+		{"8/7k/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "c1b2", "Qcb2"},
+		//Promotions, with check and mate
+		{"7k/4P3/8/8/8/8/8/4K3 w - - 0 1", "e7e8q", "e8=Q+"},
+		{"7k/4P3/8/8/8/8/8/4K3 w - - 0 1", "e7e8n", "e8=N"},
+		{"3r3k/4P1pp/8/8/8/8/8/4K3 w - - 0 1", "e7d8r", "exd8=R#"},
+		{"4k3/8/8/8/8/8/7p/4K1N1 b - - 0 1", "h2g1q", "hxg1=Q+"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1c1", "O-O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "O-O-O"},
+	}
+
+	for _, test := range tests {
+		board, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := board.ParseUCIMove(test.uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if san := board.MoveToSAN(m); san != test.san {
+			t.Errorf("%s %s: got %s, want %s", test.fen, test.uci, san, test.san)
+		}
+		if parsed, err := board.ParseSAN(test.san); err != nil || parsed != m {
+			t.Errorf("%s %s: parsed as %s, %v", test.fen, test.san, parsed.UCI(), err)
+		}
+	}
+}
+
+func TestParseSANErrors(t *testing.T) {
+	tests := []struct {
+		fen, san string
+		err      error
+	}{
+		{"2k5/8/8/8/8/8/8/R4RK1 w - - 0 1", "Rd1", ErrAmbiguousMove},
+		{"2k5/8/8/R7/8/8/8/R3K3 w - - 0 1", "Ra3", ErrAmbiguousMove},
+		{"8/7k/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "Qab2", ErrAmbiguousMove},
+		{"8/7k/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "Q1b2", ErrAmbiguousMove},
+		{"7k/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8", ErrIllegalMove},
+		{"7k/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8=K", ErrInvalidSAN},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "O-O", ErrIllegalMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nz3", ErrInvalidSAN},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "", ErrInvalidSAN},
+	}
+
+	for _, test := range tests {
+		board, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := board.ParseSAN(test.san); !errors.Is(err, test.err) {
+			t.Errorf("%s %q: got %v, want %v", test.fen, test.san, err, test.err)
+		}
+	}
+}
@@ -1,67 +1,119 @@
 package bitboard
 
 import (
-	"fmt"
-	"runtime"
+	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	int32lowest  int32         = -2147483647
-	int32highest int32         = 2147483647
-	timeWait     time.Duration = 60_000
+	int32lowest    int32 = -2147483647
+	int32highest   int32 = 2147483647
+	maxSearchDepth int   = 64
+	historyLimit   int32 = 1 << 24
 )
 
-var (
-	timeLeft bool
-)
+// Limits says when a search started with IterativeDeepening should stop.
+// Zero values mean no limit. Clock times are only used when MoveTime is zero,
+// and MoveOverhead is subtracted from the time available to cover communication delays.
+// Mate searches for a mate in at most that many moves, with the pruning that could miss one switched off, and stops as soon as one is found.
+// A search with Ponder set ignores the clock until the channel is closed, and then keeps to the time limits counted from that moment.
+type Limits struct {
+	Depth    int
+	Nodes    uint64
+	MoveTime time.Duration
+	Mate     int
+	Infinite bool
+	Ponder   <-chan struct{}
+
+	WhiteTime      time.Duration
+	BlackTime      time.Duration
+	WhiteIncrement time.Duration
+	BlackIncrement time.Duration
+	MovesToGo      int
+	MoveOverhead   time.Duration
+}
 
 type response struct {
 	move  Move
 	score int32
 }
 
-var numberToBits [8][256][]uint8
-
-func combinations(board *ChessBoard, depth int) int {
-	if depth == 0 {
+func sideIndex(blacksTurn bool) int {
+	if blacksTurn {
 		return 1
 	}
+	return 0
+}
 
-	moves := board.PsudoLegalMoves(false)
-	num := 0
-	for _, m := range moves {
-		temp := *board
-		board.DoMove(m)
-		if !board.CheckForCheck(board.BlacksTurn) {
-			add := combinations(board, depth-1)
-			num += add
+func isQuiet(board *ChessBoard, m Move) bool {
+	return board.AllPieces&m.To == 0 && !m.EnPassant && m.PawnPromotionPiece == 0
+}
+
+// updateHistory rewards a quiet move that caused a beta cutoff
+func (e *Engine) updateHistory(board *ChessBoard, m Move, depth uint8) {
+	entry := &e.history[sideIndex(board.BlacksTurn)][m.FromIndex][m.ToIndex]
+	if value := atomic.AddInt32(entry, int32(depth)*int32(depth)); value > historyLimit {
+		atomic.AddInt32(entry, -value/2)
+	}
+}
+
+// quietCutoff remembers a quiet move that caused a beta cutoff as a killer, a countermove and in the history
+func (t *thread) quietCutoff(board *ChessBoard, m Move, depth uint8, ply int) {
+	t.engine.updateHistory(board, m, depth)
+	if t.killers[ply][0] != m {
+		t.killers[ply][1] = t.killers[ply][0]
+		t.killers[ply][0] = m
+	}
+	if ply > 0 {
+		if previous := t.currentMove[ply-1]; previous.From != 0 {
+			t.counterMoves[previous.Piece-1][previous.ToIndex] = m
 		}
-		*board = temp
 	}
-	return num
 }
 
-func negaMax(board *ChessBoard, depth uint8, alpha, beta int32, age uint8) int32 {
-	if !timeLeft {
+func (t *thread) negaMax(board *ChessBoard, depth uint8, ply int, alpha, beta int32, nullAllowed bool) int32 {
+	e := t.engine
+	t.pvLength[ply] = 0
+	if e.isStopped() {
 		return 0
 	}
-	if depth == 0 {
-		return quiscence(board, alpha, beta)
+	if n := atomic.AddUint64(&e.stats.Nodes, 1); e.nodeLimit > 0 && n >= e.nodeLimit {
+		e.Stop()
 	}
 
-	repetitions := 0
-	for _, hash := range board.LastHashes {
-		if hash == board.Zobrist {
-			repetitions++
-			if repetitions == 3 {
-				return 0
-			}
-		}
+	inCheck := board.CheckForCheck(!board.BlacksTurn)
+	if inCheck && e.enabled(CheckExtensions) && ply+int(depth) < maxSearchDepth {
+		depth++
+	}
+	if depth == 0 || ply >= maxSearchDepth {
+		return t.quiscence(board, ply, alpha, beta)
+	}
+	if ply > t.selDepth {
+		t.selDepth = ply
 	}
 
-	tranBestMoveIndex, tranDepth, tranScore, tranNode, tranAge, tranMatching := GetEntry(board.Zobrist)
+	repetitions := board.Repetitions()
+	if repetitions >= 3 {
+		return 0
+	}
+
+	//No mate found from here can be shorter than being mated on the next move or mating right now
+	if matedScore(ply) > alpha {
+		alpha = matedScore(ply)
+	}
+	if -matedScore(ply+1) < beta {
+		beta = -matedScore(ply + 1)
+	}
+	if alpha >= beta {
+		return alpha
+	}
+
+	tranBestMove, tranDepth, tranScore, tranNode, _, tranMatching := e.tt.Get(board.Zobrist)
+	tranScore = scoreFromTT(tranScore, ply)
 	if tranNode != 0 && tranDepth >= depth && tranMatching && repetitions <= 1 {
+		atomic.AddUint64(&e.stats.TTHits, 1)
 		if tranNode == ExactNode {
 			return tranScore
 		}
@@ -73,82 +125,145 @@ func negaMax(board *ChessBoard, depth uint8, alpha, beta int32, age uint8) int32
 		}
 	}
 
-	var bestMoveIndex uint8
+	pvNode := beta-alpha > 1
+	staticEval := evaluate(board)
+	nonMateWindow := alpha > -mateBound && beta < mateBound
+
+	//The position is so good that even giving away a margin per ply fails high
+	if e.enabled(ReverseFutilityPruning) && !pvNode && !inCheck && nonMateWindow && depth <= reverseFutilityDepth &&
+		staticEval-reverseFutilityMargin*int32(depth) >= beta {
+		return staticEval - reverseFutilityMargin*int32(depth)
+	}
+
+	//If passing the turn still fails high, a real move almost surely will
+	if e.enabled(NullMovePruning) && nullAllowed && !pvNode && !inCheck && nonMateWindow && depth >= nullMoveDepth &&
+		staticEval >= beta && board.hasNonPawnMaterial() {
+		reduction := 3 + depth/6
+		if reduction > depth-1 {
+			reduction = depth - 1
+		}
+		temp := *board
+		board.DoNullMove()
+		t.currentMove[ply] = Move{}
+		score := -t.negaMax(board, depth-1-reduction, ply+1, -beta, -beta+1, false)
+		*board = temp
+		if score >= beta && !e.isStopped() {
+			if score >= mateBound {
+				score = beta
+			}
+			//Deep cutoffs are verified by a reduced search without null moves, to catch zugzwang with pieces on the board
+			if depth < nullMoveVerifyDepth || t.negaMax(board, depth-1-reduction, ply, beta-1, beta, false) >= beta {
+				return score
+			}
+		}
+	}
+
+	//Quiet moves that cannot raise the score to alpha are skipped near the leaves
+	futile := e.enabled(FutilityPruning) && !pvNode && !inCheck && nonMateWindow && depth <= futilityDepth &&
+		staticEval+futilityMargins[depth] <= alpha
+
+	var bestMove Move
 	bestScore := int32lowest
 	moves := board.PsudoLegalMoves(false)
-	moved := false
+	searched := 0
 
 	node := UpperBoundNode
-	save := false
-	if tranDepth > depth || tranNode == 0 || tranAge < age {
-		save = true
+
+	//A move from a colliding entry may not even be possible here
+	var ttMove Move
+	if tranMatching && tranNode != 0 {
+		if m := board.UnpackMove(tranBestMove); board.IsPseudoLegal(m) {
+			ttMove = m
+		}
 	}
+	picker := t.newMovePicker(board, moves, ttMove, ply, false)
 
-	usedTran := false
-	if tranMatching && int(tranBestMoveIndex) < len(moves) {
-		usedTran = true
+	for i, ok := picker.next(); ok; i, ok = picker.next() {
+		m := moves[i]
+		quiet := isQuiet(board, m)
 		temp := *board
-		board.DoMove(moves[tranBestMoveIndex])
-		if !board.CheckForCheck(board.BlacksTurn) {
-			moved = true
-			score := -negaMax(board, depth-1, -beta, -alpha, age)
-			if score >= beta {
-				if save {
-					StoreEntry(board.Zobrist, bestMoveIndex, depth, bestScore, LowerBoundNode, age)
-				}
-				return score
-			}
-			if score > bestScore {
-				bestScore = score
-				bestMoveIndex = tranBestMoveIndex
-				if score > alpha {
-					alpha = score
-					node = ExactNode
-				}
-			}
+		board.DoMove(m)
+		t.currentMove[ply] = m
+		if board.CheckForCheck(board.BlacksTurn) {
+			*board = temp
+			continue
+		}
+		givesCheck := board.CheckForCheck(!board.BlacksTurn)
+		if futile && searched > 0 && quiet && !givesCheck {
+			*board = temp
+			continue
+		}
+
+		var reduction uint8
+		if e.enabled(LateMoveReductions) && searched >= lmrMoves && depth >= lmrDepth && quiet && !inCheck && !givesCheck {
+			reduction = lateMoveReduction(depth, searched)
 		}
+		score := t.searchMove(board, depth-1, reduction, ply+1, alpha, beta, searched == 0)
+		searched++
 		*board = temp
-	}
 
-	for i, m := range moves {
-		if i != int(tranBestMoveIndex) || !usedTran {
-			temp := *board
-			board.DoMove(m)
-			if !board.CheckForCheck(board.BlacksTurn) {
-				moved = true
-				score := -negaMax(board, depth-1, -beta, -alpha, age)
-				if score >= beta {
-					if save {
-						StoreEntry(board.Zobrist, bestMoveIndex, depth, bestScore, LowerBoundNode, age)
-					}
-					return score
-				}
-				if score > bestScore {
-					bestScore = score
-					bestMoveIndex = uint8(i)
-					if score > alpha {
-						alpha = score
-						node = ExactNode
-					}
-				}
+		if score >= beta {
+			atomic.AddUint64(&e.stats.BetaCutoffs, 1)
+			if quiet {
+				t.quietCutoff(board, m, depth, ply)
+			}
+			if !e.isStopped() {
+				e.tt.Store(board.Zobrist, m.Pack(), depth, scoreToTT(score, ply), LowerBoundNode, e.age)
+			}
+			return score
+		}
+		if score > bestScore {
+			bestScore = score
+			bestMove = m
+			if score > alpha {
+				alpha = score
+				node = ExactNode
+				t.updatePV(ply, m)
 			}
-			*board = temp
 		}
-	}
-	if save {
-		StoreEntry(board.Zobrist, uint8(bestMoveIndex), depth, bestScore, node, age)
 	}
 
-	if !moved {
-		if !board.CheckForCheck(!board.BlacksTurn) {
+	if searched == 0 {
+		if !inCheck {
 			return 0
 		}
+		return matedScore(ply)
 	}
 
+	if !e.isStopped() {
+		e.tt.Store(board.Zobrist, bestMove.Pack(), depth, scoreToTT(bestScore, ply), node, e.age)
+	}
 	return bestScore
 }
 
-func quiscence(board *ChessBoard, alpha, beta int32) int32 {
+// searchMove searches the position after a move and returns its score for the side that moved. Every move but the first
+// is searched with a zero window, and reduced when reduction is set, and searched again when it beats alpha.
+func (t *thread) searchMove(board *ChessBoard, depth, reduction uint8, ply int, alpha, beta int32, first bool) int32 {
+	if first {
+		return -t.negaMax(board, depth, ply, -beta, -alpha, true)
+	}
+	//Without principal variation search the window stays open, and only reductions are re-searched
+	lower := -beta
+	if t.engine.enabled(PrincipalVariationSearch) {
+		lower = -alpha - 1
+	}
+	score := -t.negaMax(board, depth-reduction, ply, lower, -alpha, true)
+	if reduction > 0 && score > alpha {
+		score = -t.negaMax(board, depth, ply, lower, -alpha, true)
+	}
+	if lower != -beta && score > alpha && score < beta {
+		score = -t.negaMax(board, depth, ply, -beta, -alpha, true)
+	}
+	return score
+}
+
+func (t *thread) quiscence(board *ChessBoard, ply int, alpha, beta int32) int32 {
+	e := t.engine
+	atomic.AddUint64(&e.stats.Nodes, 1)
+	atomic.AddUint64(&e.stats.QuiescenceNodes, 1)
+	if ply > t.selDepth {
+		t.selDepth = ply
+	}
 	standPat := evaluate(board)
 	if standPat >= beta {
 		return beta
@@ -156,13 +271,26 @@ func quiscence(board *ChessBoard, alpha, beta int32) int32 {
 	if alpha < standPat {
 		alpha = standPat
 	}
+	delta := e.enabled(DeltaPruning)
+	seePruning := e.enabled(SEEPruning)
 	moves := board.PsudoLegalMoves(true)
-	for _, m := range moves {
+	picker := t.newMovePicker(board, moves, Move{}, ply, true)
+	for i, ok := picker.next(); ok; i, ok = picker.next() {
+		m := moves[i]
+		//A capture that cannot bring the score near alpha even with a margin is not worth searching
+		if delta && m.PawnPromotionPiece == 0 && !m.EnPassant && standPat+pieceValue(board.PieceOnSquare(m.To))+deltaMargin < alpha {
+			continue
+		}
+		//Captures that lose material in the exchange rarely raise alpha
+		if seePruning && !board.SEEGreaterOrEqual(m, 0) {
+			continue
+		}
 		temp := *board
 		board.DoMove(m)
 		if !board.CheckForCheck(board.BlacksTurn) {
-			score := -quiscence(board, -beta, -alpha)
+			score := -t.quiscence(board, ply+1, -beta, -alpha)
 			if score >= beta {
+				*board = temp
 				return beta
 			}
 			if score > alpha {
@@ -174,100 +302,297 @@ func quiscence(board *ChessBoard, alpha, beta int32) int32 {
 	return alpha
 }
 
-func Search(board *ChessBoard, depth uint8, age uint8) Move {
-	bestScore := int32lowest
-	var bestMove Move
+// thread is one searcher of a Lazy SMP search. All threads search the same position to increasing depths
+// and share the transposition table, so the helpers fill it with results that speed up the main thread.
+type thread struct {
+	engine    *Engine
+	id        int
+	board     ChessBoard
+	rootMoves []Move
 
-	moves := board.PsudoLegalMoves(false)
-	for _, m := range moves {
-		temp := *board
-		board.DoMove(m)
-		if !board.CheckForCheck(board.BlacksTurn) {
-			score := -negaMax(board, depth-1, int32lowest, int32highest, age)
-			if score >= bestScore {
-				bestScore = score
-				bestMove = m
-			}
-		}
-		*board = temp
-	}
-	return bestMove
+	//pv[ply] holds the best line found from the node at ply, it is built from the line of the next ply
+	pv       [maxSearchDepth + 1][maxSearchDepth + 1]Move
+	pvLength [maxSearchDepth + 1]int
+	selDepth int
+
+	//currentMove[ply] is the move being searched at ply, and is used to look up countermoves
+	currentMove  [maxSearchDepth + 1]Move
+	killers      [maxSearchDepth + 1][2]Move
+	counterMoves [12][64]Move
+
+	depth int
+	lines []rootLine
 }
 
-func SearchMultiProcessing(board *ChessBoard, depth uint8, age uint8) Move {
-	runtime.GOMAXPROCS(runtime.NumCPU())
+// rootLine is one of the MultiPV lines, lines[i] starts with rootMoves[i]
+type rootLine struct {
+	score int32
+	pv    []Move
+}
 
-	bestScore := int32lowest
-	var bestMove Move
+func (e *Engine) newThread(id int, board *ChessBoard) *thread {
+	t := &thread{engine: e, id: id, board: *board}
+	t.board.LastHashes = append([]uint64(nil), board.LastHashes...)
+	t.rootMoves = t.board.LegalMoves()
 
-	moves := board.PsudoLegalMoves(false)
+	multiPV := e.options.MultiPV
+	if multiPV > len(t.rootMoves) {
+		multiPV = len(t.rootMoves)
+	}
+	t.lines = make([]rootLine, multiPV)
+	return t
+}
 
-	each := len(moves) / runtime.NumCPU()
-	extra := len(moves) % runtime.NumCPU()
-	add := 0
-	channel := make(chan response)
+// updatePV makes m followed by the line of the next ply the best line at ply
+func (t *thread) updatePV(ply int, m Move) {
+	t.pv[ply][0] = m
+	length := 0
+	if ply+1 <= maxSearchDepth {
+		length = copy(t.pv[ply][1:], t.pv[ply+1][:t.pvLength[ply+1]])
+	}
+	t.pvLength[ply] = length + 1
+}
 
-	for i := 0; i < runtime.NumCPU(); i++ {
-		var m []Move
-		if extra == 0 {
-			m = moves[i*each+add : i*each+add+each]
-		} else {
-			m = moves[i*each+add : i*each+add+each+1]
-			extra--
-			add++
+// searchRoot searches the root moves from first on, which excludes the moves of the better MultiPV lines.
+// The best move found is placed at first, so it is also searched first at the next depth.
+// A score at or below alpha is an upper bound, and the search returns at the first move scoring at least beta.
+// If the search is stopped the result only covers the moves searched so far, and complete is false.
+func (t *thread) searchRoot(depth uint8, first int, alpha, beta int32) (resp response, complete bool) {
+	moves := t.rootMoves[first:]
+	resp.score = int32lowest
+	bestIndex := 0
+	defer func() {
+		best := moves[bestIndex]
+		copy(moves[1:bestIndex+1], moves[:bestIndex])
+		moves[0] = best
+	}()
+
+	for i, m := range moves {
+		temp := t.board
+		t.board.DoMove(m)
+		t.currentMove[0] = m
+		score := t.searchMove(&t.board, depth-1, 0, 1, alpha, beta, i == 0)
+		t.board = temp
+		if t.engine.isStopped() {
+			return resp, false
+		}
+		if score > alpha || resp.move.From == 0 {
+			if score > alpha && score < beta {
+				t.updatePV(0, m)
+			} else {
+				//The line after a move outside the window is not reliable
+				t.pv[0][0] = m
+				t.pvLength[0] = 1
+			}
+			resp = response{m, score}
+			bestIndex = i
+			if score >= beta {
+				break
+			}
+			if score > alpha {
+				alpha = score
+			}
 		}
-		boardCopy := *board
-		boardCopy.Init()
+	}
+	return resp, true
+}
 
-		go SearchProcess(m, channel, &boardCopy, depth, age)
+// aspirate searches the root with a narrow window around the score of the last depth, which is widened when the
+// score falls outside it. Mate scores and the first depths are searched with a full window.
+func (t *thread) aspirate(depth uint8, first int) (response, bool) {
+	last := t.lines[first]
+	if !t.engine.enabled(AspirationWindows) || depth < 4 || len(last.pv) == 0 || mateIn(last.score) != 0 {
+		return t.searchRoot(depth, first, int32lowest, int32highest)
 	}
 
-	for i := 0; i < runtime.NumCPU(); i++ {
-		resp := <-channel
-		if resp.score >= bestScore {
-			bestScore = resp.score
-			bestMove = resp.move
+	delta := aspirationWindow
+	alpha, beta := last.score-delta, last.score+delta
+	for {
+		resp, complete := t.searchRoot(depth, first, alpha, beta)
+		if !complete && resp.score <= alpha {
+			//The best move of the last depth failed low, but there was no time to find a better one
+			return response{}, false
+		}
+		if !complete || (resp.score > alpha && resp.score < beta) {
+			return resp, complete
+		}
+		delta *= 2
+		if resp.score <= alpha {
+			alpha = resp.score - delta
+		} else {
+			beta = resp.score + delta
+		}
+		if delta >= aspirationFullWindow {
+			alpha, beta = int32lowest, int32highest
 		}
 	}
-
-	return bestMove
 }
 
-func SearchProcess(moves []Move, channel chan response, board *ChessBoard, depth uint8, age uint8) {
-	bestScore := int32lowest
-	var bestMove Move
-
-	for _, m := range moves {
-		temp := *board
-		board.DoMove(m)
-		if !board.CheckForCheck(board.BlacksTurn) {
-			score := -negaMax(board, depth-1, int32lowest, int32highest, age)
-			if score >= bestScore {
-				bestScore = score
-				bestMove = m
+// iterate searches one depth deeper at a time until maxDepth or the engine is stopped.
+// completed, if not nil, is called after each completed depth and ends the search by returning false.
+func (t *thread) iterate(maxDepth int, completed func(depth int) bool) {
+	//Half of the helpers start one depth later, so the threads spread over two depths
+	for depth := 1 + t.id%2; depth <= maxDepth && !t.engine.isStopped(); depth++ {
+		t.selDepth = 0
+		for i := range t.lines {
+			resp, complete := t.aspirate(uint8(depth), i)
+			if resp.move.From != 0 {
+				//A line is only replaced when a move was fully searched, so a stopped depth still has valid lines
+				t.lines[i] = rootLine{resp.score, append(t.lines[i].pv[:0], t.pv[0][:t.pvLength[0]]...)}
+			}
+			if !complete {
+				return
 			}
 		}
-		*board = temp
+		t.sortLines()
+		t.depth = depth
+		if completed != nil && !completed(depth) {
+			return
+		}
 	}
-	channel <- response{bestMove, bestScore}
 }
 
-func IterativeDeepening(board *ChessBoard) Move {
-	timeLeft = true
-	go timer()
-	var bestMove Move
-	for depth := 1; timeLeft; depth++ {
-		newMove := SearchMultiProcessing(board, uint8(depth), 0)
-		if timeLeft {
-			bestMove = newMove
-		} else {
-			fmt.Printf("Depth: %v\n", depth-1)
+// sortLines ranks the lines of a completed depth by score, keeping rootMoves in the same order.
+// Each line excludes the moves of the lines before it, but aspiration windows and the shared
+// transposition table can still give a later line a higher score.
+func (t *thread) sortLines() {
+	for i := 1; i < len(t.lines); i++ {
+		for j := i; j > 0 && t.lines[j].score > t.lines[j-1].score; j-- {
+			t.lines[j], t.lines[j-1] = t.lines[j-1], t.lines[j]
+			t.rootMoves[j], t.rootMoves[j-1] = t.rootMoves[j-1], t.rootMoves[j]
 		}
 	}
-	return bestMove
 }
 
-func timer() {
-	time.Sleep(timeWait * time.Millisecond)
-	timeLeft = false
+// Search searches to a fixed depth on one goroutine. If ctx is cancelled the result covers the fully searched root moves.
+func (e *Engine) Search(ctx context.Context, board *ChessBoard, depth uint8) SearchResult {
+	start := time.Now()
+	defer e.start(ctx, 0)()
+
+	t := e.newThread(0, board)
+	if len(t.rootMoves) == 0 {
+		return SearchResult{}
+	}
+	t.iterate(int(depth), func(int) bool { return true })
+	return e.result(t, 0, board, start)
+}
+
+// IterativeDeepening searches one depth deeper at a time until a limit is reached or ctx is done.
+// The search is run by Options.Threads goroutines sharing the transposition table.
+// info, if not nil, is called after each depth completed by the main thread with the Options.MultiPV best lines in order,
+// and the best line is returned.
+func (e *Engine) IterativeDeepening(ctx context.Context, board *ChessBoard, limits Limits, info func(SearchResult)) SearchResult {
+	start := time.Now()
+	tm := newTimeManager(limits, board.BlacksTurn, start)
+	if tm.hard > 0 && limits.Ponder == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tm.hard)
+		defer cancel()
+	}
+	defer e.start(ctx, limits.Nodes)()
+
+	if limits.Mate > 0 {
+		defer func(disabled Feature) { e.options.Disabled = disabled }(e.options.Disabled)
+		e.options.Disabled |= mateSearchDisabled
+	}
+
+	var tmMutex sync.Mutex
+	pondering := limits.Ponder != nil
+	if pondering {
+		finished := make(chan struct{})
+		var waiter sync.WaitGroup
+		//The waiter must be gone before returning, or its Stop could hit the next search
+		defer waiter.Wait()
+		defer close(finished)
+		waiter.Add(1)
+		go func() {
+			defer waiter.Done()
+			select {
+			case <-limits.Ponder:
+			case <-finished:
+				return
+			}
+			tmMutex.Lock()
+			pondering = false
+			tm = newTimeManager(limits, board.BlacksTurn, time.Now())
+			hard := tm.hard
+			tmMutex.Unlock()
+			if hard == 0 {
+				return
+			}
+			timer := time.NewTimer(hard)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+				e.Stop()
+			case <-finished:
+			}
+		}()
+	}
+
+	maxDepth := limits.Depth
+	if maxDepth <= 0 || maxDepth > maxSearchDepth {
+		maxDepth = maxSearchDepth
+	}
+	if limits.Mate > 0 && 2*limits.Mate < maxDepth {
+		maxDepth = 2 * limits.Mate
+	}
+
+	threads := make([]*thread, e.options.Threads)
+	for i := range threads {
+		threads[i] = e.newThread(i, board)
+	}
+	if len(threads[0].rootMoves) == 0 {
+		return SearchResult{}
+	}
+
+	var helpers sync.WaitGroup
+	for _, t := range threads[1:] {
+		helpers.Add(1)
+		go func(t *thread) {
+			defer helpers.Done()
+			t.iterate(maxDepth, nil)
+		}(t)
+	}
+
+	mainThread := threads[0]
+	mainThread.iterate(maxDepth, func(depth int) bool {
+		if info != nil {
+			for i := range mainThread.lines {
+				info(e.result(mainThread, i, board, start))
+			}
+		}
+		best := mainThread.lines[0]
+		if mate := mateIn(best.score); limits.Mate > 0 && mate > 0 && mate <= limits.Mate {
+			return false
+		}
+		//A mate that fits within the depth searched is proven, deeper searches would only find it again
+		if mateIn(best.score) != 0 {
+			plies := MateScore - best.score
+			if best.score < 0 {
+				plies = MateScore + best.score
+			}
+			if int(plies) <= depth {
+				return false
+			}
+		}
+		tmMutex.Lock()
+		defer tmMutex.Unlock()
+		if pondering {
+			return true
+		}
+		tm.update(best.pv[0], best.score)
+		return !tm.stop()
+	})
+	e.Stop()
+	helpers.Wait()
+
+	//A helper that got deeper than the main thread has the more reliable line
+	best := mainThread
+	for _, t := range threads[1:] {
+		if t.depth > best.depth && len(t.lines[0].pv) > 0 {
+			best = t
+		}
+	}
+	return e.result(best, 0, board, start)
 }
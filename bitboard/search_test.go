@@ -0,0 +1,96 @@
+package bitboard
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+var benchmarkPositions = []struct {
+	name string
+	fen  string
+}{
+	{"Start", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+	{"Kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"},
+	{"Endgame", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1"},
+}
+
+func BenchmarkSearch(b *testing.B) {
+	engine := NewEngine(Options{})
+	for _, position := range benchmarkPositions {
+		board, err := ParseFEN(position.fen)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(position.name, func(b *testing.B) {
+			nodes := uint64(0)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				engine.NewGame()
+				b.StartTimer()
+				engine.Search(context.Background(), &board, 7)
+				nodes += engine.Stats().Nodes
+			}
+			b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+		})
+	}
+}
+
+// BenchmarkLazySMP measures the time to depth of IterativeDeepening with more threads,
+// which only goes down when the machine has a core for each of them
+func BenchmarkLazySMP(b *testing.B) {
+	for _, threads := range []int{1, 2, 4} {
+		engine := NewEngine(Options{Threads: threads})
+		for _, position := range benchmarkPositions {
+			board, err := ParseFEN(position.fen)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(fmt.Sprintf("threads=%d/%s", threads, position.name), func(b *testing.B) {
+				nodes := uint64(0)
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					engine.NewGame()
+					b.StartTimer()
+					result := engine.IterativeDeepening(context.Background(), &board, Limits{Depth: 8}, nil)
+					nodes += result.Nodes
+				}
+				b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+			})
+		}
+	}
+}
+
+func TestMultiPV(t *testing.T) {
+	for _, threads := range []int{1, 4} {
+		engine := NewEngine(Options{Threads: threads, MultiPV: 3})
+		for _, position := range benchmarkPositions {
+			board, err := ParseFEN(position.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lines []SearchResult
+			engine.IterativeDeepening(context.Background(), &board, Limits{Depth: 7}, func(info SearchResult) {
+				if info.MultiPV == 1 {
+					lines = lines[:0]
+				}
+				lines = append(lines, info)
+				if info.MultiPV != len(lines) {
+					t.Fatalf("%s: got multipv %d after %d lines", position.name, info.MultiPV, len(lines)-1)
+				}
+				for i, line := range lines[:len(lines)-1] {
+					if line.BestMove == info.BestMove {
+						t.Errorf("%s depth %d: lines %d and %d both start with %s", position.name, info.Depth, i+1, info.MultiPV, info.BestMove.UCI())
+					}
+				}
+				if len(lines) < 2 {
+					return
+				}
+				if previous := lines[len(lines)-2]; info.Score > previous.Score {
+					t.Errorf("%s with %d threads, depth %d: line %d scores %d, more than %d for line %d",
+						position.name, threads, info.Depth, info.MultiPV, info.Score, previous.Score, previous.MultiPV)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package bitboard
+
+import "math/bits"
+
+// seeValues are the piece values used by the static exchange evaluation, indexed like mgValues.
+// The king is worth more than everything else together, so it only captures last.
+var seeValues [6]int32 = [6]int32{100, 500, 320, 330, 900, 20000}
+
+func seeValue(piece PieceType) int32 {
+	if piece == 0 {
+		return 0
+	}
+	return seeValues[whitePiece(piece)-1]
+}
+
+// allAttackersTo finds the pieces of both sides attacking square, seen through the occupied squares.
+// Pieces that are not in occupied have already been used in the exchange and are left out.
+func (board *ChessBoard) allAttackersTo(square Bitboard, occupied Bitboard) Bitboard {
+	index := uint8(bits.TrailingZeros64(uint64(square)))
+	attackers := PawnAttacks(index, false)&board.BlackPawns | PawnAttacks(index, true)&board.WhitePawns
+	attackers |= KnightAttacks(index) & (board.WhiteKnights | board.BlackKnights)
+	attackers |= KingAttacks(index) & (board.WhiteKing | board.BlackKing)
+	attackers |= RookAttacks(index, occupied) & (board.WhiteRooks | board.BlackRooks | board.WhiteQueens | board.BlackQueens)
+	attackers |= BishopAttacks(index, occupied) & (board.WhiteBishops | board.BlackBishops | board.WhiteQueens | board.BlackQueens)
+	return attackers & occupied
+}
+
+// leastValuableAttacker returns the square and type of the cheapest piece among attackers of one side
+func (board *ChessBoard) leastValuableAttacker(attackers Bitboard, black bool) (Bitboard, PieceType) {
+	first := WhitePawn
+	if black {
+		first = BlackPawn
+	}
+	//Piece types in order of value: pawn, knight, bishop, rook, queen, king
+	for _, offset := range [6]PieceType{0, 2, 3, 1, 4, 5} {
+		piece := first + offset
+		if found := attackers & *board.pieceBitboard(piece); found > 0 {
+			return found & -found, piece
+		}
+	}
+	return 0, 0
+}
+
+// SEE statically evaluates the exchange started by m on its destination square, assuming both sides keep
+// recapturing with their cheapest piece as long as it pays off. The result is the material won in centipawns
+// from the view of the side making m. Pieces behind the capturing ones join in as x-ray attackers.
+func (board *ChessBoard) SEE(m Move) int32 {
+	if m.LongCastle || m.ShortCastle {
+		return 0
+	}
+	var gain [32]int32
+	occupied := board.AllPieces &^ m.From
+
+	gain[0] = seeValue(board.PieceOnSquare(m.To))
+	onSquare := seeValue(m.Piece)
+	if m.EnPassant {
+		gain[0] = seeValues[0]
+		if board.BlacksTurn {
+			occupied &^= m.To << 8
+		} else {
+			occupied &^= m.To >> 8
+		}
+	}
+	if m.PawnPromotionPiece != 0 {
+		gain[0] += seeValue(m.PawnPromotionPiece) - seeValues[0]
+		onSquare = seeValue(m.PawnPromotionPiece)
+	}
+
+	attackers := board.allAttackersTo(m.To, occupied)
+	black := !board.BlacksTurn
+	depth := 0
+	for depth < len(gain)-1 {
+		square, piece := board.leastValuableAttacker(attackers, black)
+		if square == 0 {
+			break
+		}
+		//The king may not capture into a square the other side still attacks
+		if whitePiece(piece) == WhiteKing && board.leastValuableAttackerExists(attackers&^square, !black) {
+			break
+		}
+		depth++
+		gain[depth] = onSquare - gain[depth-1]
+		onSquare = seeValue(piece)
+		occupied &^= square
+		attackers = board.allAttackersTo(m.To, occupied)
+		black = !black
+	}
+
+	//Each side may stop capturing when continuing would lose material
+	for ; depth > 0; depth-- {
+		if -gain[depth] < gain[depth-1] {
+			gain[depth-1] = -gain[depth]
+		}
+	}
+	return gain[0]
+}
+
+func (board *ChessBoard) leastValuableAttackerExists(attackers Bitboard, black bool) bool {
+	square, _ := board.leastValuableAttacker(attackers, black)
+	return square > 0
+}
+
+// SEEGreaterOrEqual reports whether SEE(m) is at least threshold. It is faster than SEE,
+// since it stops as soon as the answer is known.
+func (board *ChessBoard) SEEGreaterOrEqual(m Move, threshold int32) bool {
+	if m.LongCastle || m.ShortCastle {
+		return threshold <= 0
+	}
+	occupied := board.AllPieces &^ m.From
+
+	captured := seeValue(board.PieceOnSquare(m.To))
+	onSquare := seeValue(m.Piece)
+	if m.EnPassant {
+		captured = seeValues[0]
+		if board.BlacksTurn {
+			occupied &^= m.To << 8
+		} else {
+			occupied &^= m.To >> 8
+		}
+	}
+	if m.PawnPromotionPiece != 0 {
+		captured += seeValue(m.PawnPromotionPiece) - seeValues[0]
+		onSquare = seeValue(m.PawnPromotionPiece)
+	}
+
+	//balance is what the side making m has won so far minus the threshold
+	balance := captured - threshold
+	if balance < 0 {
+		return false
+	}
+	//Even losing the moved piece for nothing keeps the threshold
+	if balance-onSquare >= 0 {
+		return true
+	}
+
+	attackers := board.allAttackersTo(m.To, occupied)
+	black := !board.BlacksTurn
+	ourTurn := false
+	for {
+		square, piece := board.leastValuableAttacker(attackers, black)
+		if square == 0 {
+			return !ourTurn
+		}
+		if whitePiece(piece) == WhiteKing && board.leastValuableAttackerExists(attackers&^square, !black) {
+			return !ourTurn
+		}
+		//The side to capture takes the piece on the square, and may lose its own capturing piece next
+		if ourTurn {
+			balance += onSquare
+		} else {
+			balance -= onSquare
+		}
+		onSquare = seeValue(piece)
+		occupied &^= square
+		attackers = board.allAttackersTo(m.To, occupied)
+		black = !black
+
+		//The side that just captured is done if it stays on the right side of the threshold even after losing its piece
+		if ourTurn && balance-onSquare >= 0 {
+			return true
+		}
+		if !ourTurn && balance+onSquare < 0 {
+			return false
+		}
+		ourTurn = !ourTurn
+	}
+}
+
+// HangingPieces returns the pieces of the side to move that the opponent could capture with a material gain.
+// It returns nothing while the side to move is in check, since the opponent can not move then.
+func (board *ChessBoard) HangingPieces() Bitboard {
+	if board.CheckForCheck(!board.BlacksTurn) {
+		return 0
+	}
+	opponent := *board
+	opponent.DoNullMove()
+	var hanging Bitboard
+	for _, m := range opponent.PsudoLegalMoves(true) {
+		if hanging&m.To == 0 && opponent.SEE(m) > 0 && opponent.IsLegal(m) {
+			hanging |= m.To
+		}
+	}
+	return hanging
+}
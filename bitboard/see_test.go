@@ -0,0 +1,52 @@
+package bitboard
+
+import "testing"
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		fen, uci string
+		want     int32
+	}{
+		{"1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},
+		{"1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3e5", -220},
+		{"4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", "e4d5", 100},
+		{"4k3/2p5/3p4/4P3/8/8/8/4K3 w - - 0 1", "e5d6", 0},
+		{"4k3/8/8/8/8/8/8/3RK3 w - - 0 1", "d1d8", -500},
+		//Pieces behind the capturing ones join in
+		{"4k3/3r4/8/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", 100},
+		{"4k3/3r4/3r4/3p4/8/8/3R4/3QK3 w - - 0 1", "d2d5", -400},
+		{"3k4/3q4/8/8/8/8/3R4/3K4 w - - 0 1", "d2d7", 400},
+		{"3k4/3q4/8/8/8/8/3R4/3R3K w - - 0 1", "d2d7", 900},
+		{"4k3/8/2q5/3p4/4P3/5B2/8/4K3 w - - 0 1", "e4d5", 100},
+		//En passant takes a pawn that is not on the destination square
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 100},
+		{"4k3/2p5/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 0},
+		{"4k3/8/8/8/3Pp3/8/8/3RK3 b - d3 0 1", "e4d3", 0},
+		//A promotion wins the difference between the new piece and the pawn
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q", 800},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8n", 220},
+		{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7a8q", -100},
+		{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7b8q", 1300},
+		{"2r5/1P1k4/8/8/8/8/8/4K3 w - - 0 1", "b7c8q", 400},
+		{"4k3/8/8/8/8/8/6p1/4K2R b - - 0 1", "g2h1q", 1300},
+		//Castling never exchanges material
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", 0},
+	}
+
+	for _, test := range tests {
+		board, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := board.ParseUCIMove(test.uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := board.SEE(m); got != test.want {
+			t.Errorf("%s %s: SEE is %d, want %d", test.fen, test.uci, got, test.want)
+		}
+		if !board.SEEGreaterOrEqual(m, test.want) || board.SEEGreaterOrEqual(m, test.want+1) {
+			t.Errorf("%s %s: SEEGreaterOrEqual does not agree with %d", test.fen, test.uci, test.want)
+		}
+	}
+}
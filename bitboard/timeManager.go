@@ -0,0 +1,140 @@
+package bitboard
+
+import "time"
+
+const (
+	DefaultMoveOverhead time.Duration = 30 * time.Millisecond
+
+	defaultMovesToGo int   = 30
+	maxMovesToGo     int   = 50
+	scoreDropMargin  int32 = 30
+)
+
+// timeManager decides how long to think about a move. The soft limit is the time we aim to use,
+// it is stretched while the best move keeps changing or the score drops. The hard limit is never exceeded.
+type timeManager struct {
+	start time.Time
+	soft  time.Duration
+	hard  time.Duration
+	fixed bool
+
+	lastIteration time.Duration
+	iterationTime time.Duration
+	completed     time.Duration
+	bestMove      Move
+	score         int32
+	instability   float64
+	scoreDrop     float64
+}
+
+func newTimeManager(limits Limits, blacksTurn bool, start time.Time) timeManager {
+	tm := timeManager{start: start}
+	if limits.Infinite {
+		return tm
+	}
+
+	if limits.MoveTime > 0 {
+		tm.fixed = true
+		tm.hard = limits.MoveTime - limits.MoveOverhead
+		if tm.hard < time.Millisecond {
+			tm.hard = time.Millisecond
+		}
+		tm.soft = tm.hard
+		return tm
+	}
+
+	if limits.WhiteTime == 0 && limits.BlackTime == 0 && limits.WhiteIncrement == 0 && limits.BlackIncrement == 0 {
+		//No clock, the search only stops at the depth or node limit, or when told to
+		return tm
+	}
+	remaining, increment := limits.WhiteTime, limits.WhiteIncrement
+	if blacksTurn {
+		remaining, increment = limits.BlackTime, limits.BlackIncrement
+	}
+	//A clock at zero or below still has to get a move out, the increment only arrives after it
+	remaining -= limits.MoveOverhead
+	if remaining < time.Millisecond {
+		remaining = time.Millisecond
+	}
+
+	movesToGo := limits.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = defaultMovesToGo
+	}
+	if movesToGo > maxMovesToGo {
+		movesToGo = maxMovesToGo
+	}
+
+	tm.soft = remaining/time.Duration(movesToGo) + increment*3/4
+	tm.hard = tm.soft * 4
+	if movesToGo == 1 {
+		//Last move before the time control, the increment is not needed for later moves
+		if tm.hard > remaining*9/10 {
+			tm.hard = remaining * 9 / 10
+		}
+	} else if tm.hard > remaining*3/4 {
+		tm.hard = remaining * 3 / 4
+	}
+	if tm.soft < time.Millisecond {
+		tm.soft = time.Millisecond
+	}
+	if tm.hard < time.Millisecond {
+		tm.hard = time.Millisecond
+	}
+	if tm.soft > tm.hard {
+		tm.soft = tm.hard
+	}
+	return tm
+}
+
+// update is called after each completed depth with its best move and score
+func (tm *timeManager) update(bestMove Move, score int32) {
+	elapsed := time.Since(tm.start)
+	tm.lastIteration, tm.iterationTime = tm.iterationTime, elapsed-tm.completed
+	tm.completed = elapsed
+
+	tm.instability *= 0.6
+	if tm.bestMove.From != 0 {
+		if bestMove != tm.bestMove {
+			tm.instability += 1
+		}
+		if drop := int64(tm.score) - int64(score); drop > int64(scoreDropMargin) {
+			tm.scoreDrop = float64(drop) / 100
+			if tm.scoreDrop > 1 {
+				tm.scoreDrop = 1
+			}
+		} else {
+			tm.scoreDrop /= 2
+		}
+	}
+	tm.bestMove = bestMove
+	tm.score = score
+}
+
+// stop reports whether another iteration should not be started
+func (tm *timeManager) stop() bool {
+	if tm.hard == 0 || tm.fixed {
+		return false
+	}
+	elapsed := time.Since(tm.start)
+
+	soft := time.Duration(float64(tm.soft) * (1 + tm.instability + tm.scoreDrop))
+	if soft > tm.hard {
+		soft = tm.hard
+	}
+	if elapsed >= soft {
+		return true
+	}
+
+	//Each depth usually takes a few times longer than the last, do not start one that cannot finish
+	growth := 2.0
+	if tm.lastIteration > 0 {
+		growth = float64(tm.iterationTime) / float64(tm.lastIteration)
+		if growth < 1.5 {
+			growth = 1.5
+		} else if growth > 6 {
+			growth = 6
+		}
+	}
+	return elapsed+time.Duration(float64(tm.iterationTime)*growth) > tm.hard
+}
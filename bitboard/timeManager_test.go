@@ -0,0 +1,41 @@
+package bitboard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTimeManager(t *testing.T) {
+	const ms = time.Millisecond
+	tests := []struct {
+		name       string
+		limits     Limits
+		blacksTurn bool
+		soft, hard time.Duration
+		fixed      bool
+	}{
+		{"infinite", Limits{Infinite: true, WhiteTime: 60000 * ms}, false, 0, 0, false},
+		{"no clock", Limits{Depth: 10, MoveOverhead: 30 * ms}, false, 0, 0, false},
+		{"move time", Limits{MoveTime: 100 * ms, MoveOverhead: 30 * ms}, false, 70 * ms, 70 * ms, true},
+		{"move time below overhead", Limits{MoveTime: 10 * ms, MoveOverhead: 30 * ms}, false, ms, ms, true},
+		{"sudden death", Limits{WhiteTime: 60030 * ms, BlackTime: ms, MoveOverhead: 30 * ms}, false, 2000 * ms, 8000 * ms, false},
+		{"black clock", Limits{WhiteTime: ms, BlackTime: 60030 * ms, MoveOverhead: 30 * ms}, true, 2000 * ms, 8000 * ms, false},
+		{"zero time", Limits{WhiteTime: 0, BlackTime: 60000 * ms, MoveOverhead: 30 * ms}, false, ms, ms, false},
+		{"negative time", Limits{WhiteTime: -500 * ms, BlackTime: 60000 * ms, MoveOverhead: 30 * ms}, false, ms, ms, false},
+		{"zero time with increment", Limits{WhiteIncrement: 10000 * ms, BlackIncrement: 10000 * ms, MoveOverhead: 30 * ms}, false, ms, ms, false},
+		{"below overhead", Limits{WhiteTime: 20 * ms, BlackTime: 60000 * ms, MoveOverhead: 30 * ms}, false, ms, ms, false},
+		{"one move to go", Limits{WhiteTime: 10030 * ms, BlackTime: 10030 * ms, MovesToGo: 1, MoveOverhead: 30 * ms}, false, 9000 * ms, 9000 * ms, false},
+		{"one move to go with increment", Limits{WhiteTime: 10030 * ms, BlackTime: 10030 * ms, WhiteIncrement: 1000 * ms, MovesToGo: 1, MoveOverhead: 30 * ms}, false, 9000 * ms, 9000 * ms, false},
+		{"ten moves to go", Limits{WhiteTime: 10030 * ms, BlackTime: 10030 * ms, MovesToGo: 10, MoveOverhead: 30 * ms}, false, 1000 * ms, 4000 * ms, false},
+		{"too many moves to go", Limits{WhiteTime: 100030 * ms, BlackTime: 100030 * ms, MovesToGo: 100, MoveOverhead: 30 * ms}, false, 2000 * ms, 8000 * ms, false},
+		{"large increment", Limits{WhiteTime: 10030 * ms, BlackTime: 10030 * ms, WhiteIncrement: 30000 * ms, BlackIncrement: 30000 * ms, MoveOverhead: 30 * ms}, false, 7500 * ms, 7500 * ms, false},
+		{"increment", Limits{WhiteTime: 30030 * ms, BlackTime: 30030 * ms, WhiteIncrement: 1000 * ms, BlackIncrement: 1000 * ms, MoveOverhead: 30 * ms}, false, 1750 * ms, 7000 * ms, false},
+	}
+
+	for _, test := range tests {
+		tm := newTimeManager(test.limits, test.blacksTurn, time.Now())
+		if tm.soft != test.soft || tm.hard != test.hard || tm.fixed != test.fixed {
+			t.Errorf("%s: got soft %v, hard %v, fixed %v, want soft %v, hard %v, fixed %v", test.name, tm.soft, tm.hard, tm.fixed, test.soft, test.hard, test.fixed)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package bitboard
 
 import (
+	"math/bits"
+	"sync/atomic"
 	"unsafe"
 )
 
+// Entry is read and written with atomics, and Zobrist holds the key XORed with Data.
+// If two threads write the same entry at once the halves no longer match and the entry is ignored.
 type Entry struct {
 	Zobrist uint64
 	Data    uint64
@@ -13,7 +17,7 @@ type NodeType uint8
 
 type Position struct {
 	Zobrist  uint64
-	BestMove Move
+	BestMove PackedMove
 	Depth    uint8
 	Score    int32
 	Node     NodeType
@@ -27,38 +31,137 @@ const (
 
 	Mask8bit  uint64 = 0x00000000000000ff
 	Mask16bit uint64 = 0x000000000000ffff
+	Mask24bit uint64 = 0x0000000000ffffff
 	Mask32bit uint64 = 0x00000000ffffffff
 )
 
-const tableSize uint64 = 100_000
+const (
+	DefaultHashSize int = 16
+	MaxHashSize     int = 4096
+
+	// bucketEntries entries fill a 64 byte cache line, so probing a bucket costs one memory access
+	bucketEntries int = 4
+	bucketSize    int = int(unsafe.Sizeof(bucket{}))
+
+	// An entry from an older search is worth this many plies less when choosing what to replace
+	agePenalty int = 8
+	// An entry is kept over a shallower result for the same position from the same search, unless that result is exact
+	keepDepth uint8 = 2
 
-var transpositionTable [tableSize]Entry
+	hashfullSample int = 1000
+)
+
+type bucket [bucketEntries]Entry
 
-func StoreEntry(zobrist uint64, bestMoveIndex uint8, depth uint8, score int32, node NodeType, age uint8) {
-	bestMoveIndexData := uint64(bestMoveIndex)                  //8-bit
-	depthData := uint64(depth)                                  //8-bit
-	scoreData := *(*uint64)(unsafe.Pointer(&score)) & Mask32bit //32-bit
-	nodeData := uint64(node)                                    //8-bit
-	ageData := uint64(age)                                      //8-bit
+// TranspositionTable remembers the results of searched positions. Every position maps to a bucket of a few entries,
+// and a new result replaces the entry in its bucket that is shallowest and from the oldest search.
+type TranspositionTable struct {
+	buckets []bucket
+}
 
-	data := (bestMoveIndexData) | (depthData << 8) | (scoreData << 16) | (nodeData << 48) | (ageData << 56)
+// NewTranspositionTable makes a table using about megabytes of memory, at least one bucket is always allocated
+func NewTranspositionTable(megabytes int) *TranspositionTable {
+	tt := &TranspositionTable{}
+	tt.Resize(megabytes)
+	return tt
+}
 
-	index := zobrist % tableSize
-	transpositionTable[index].Zobrist = zobrist ^ data
-	transpositionTable[index].Data = data
+// Resize reallocates the table, which clears it
+func (tt *TranspositionTable) Resize(megabytes int) {
+	size := megabytes * 1024 * 1024 / bucketSize
+	if size < 1 {
+		size = 1
+	}
+	tt.buckets = make([]bucket, size)
 }
 
-func GetEntry(zobrist uint64) (uint8, uint8, int32, NodeType, uint8, bool) {
-	index := zobrist % tableSize
-	matching := false
-	if transpositionTable[index].Zobrist^transpositionTable[index].Data == zobrist {
-		matching = true
+func (tt *TranspositionTable) Clear() {
+	for i := range tt.buckets {
+		tt.buckets[i] = bucket{}
 	}
-	data := transpositionTable[index].Data
-	bestMoveIndexData := data & Mask8bit
-	depthData := (data >> 8) & Mask8bit
-	scoreData := (data >> 16) & Mask32bit
+}
+
+// bucket finds the bucket of a position. The high half of the key times the number of buckets spreads
+// keys evenly over a table of any size, without the division a remainder would need.
+func (tt *TranspositionTable) bucket(zobrist uint64) *bucket {
+	index, _ := bits.Mul64(zobrist, uint64(len(tt.buckets)))
+	return &tt.buckets[index]
+}
+
+// Store saves the result of a search. Scores must fit in 24 bits, which mate scores do.
+func (tt *TranspositionTable) Store(zobrist uint64, bestMove PackedMove, depth uint8, score int32, node NodeType, age uint8) {
+	bestMoveData := uint64(bestMove)               //16-bit
+	depthData := uint64(depth)                     //8-bit
+	scoreData := uint64(uint32(score)) & Mask24bit //24-bit
+	nodeData := uint64(node)                       //8-bit
+	ageData := uint64(age)                         //8-bit
+
+	data := (bestMoveData) | (depthData << 16) | (scoreData << 24) | (nodeData << 48) | (ageData << 56)
+
+	b := tt.bucket(zobrist)
+	var replace *Entry
+	replaceValue := 0
+	for i := range b {
+		entry := &b[i]
+		oldData := atomic.LoadUint64(&entry.Data)
+		oldKey := atomic.LoadUint64(&entry.Zobrist) ^ oldData
+		_, oldDepth, _, oldNode, oldAge := decodeEntry(oldData)
+		if oldKey == zobrist && oldNode != 0 {
+			if oldAge == age && oldDepth > depth+keepDepth && node != ExactNode {
+				return
+			}
+			replace = entry
+			break
+		}
+		//Empty entries are used first, then the shallowest, where every search since the entry was stored costs it some depth
+		value := int(oldDepth) - agePenalty*int(age-oldAge)
+		if oldNode == 0 {
+			value = -1 << 16
+		}
+		if replace == nil || value < replaceValue {
+			replace, replaceValue = entry, value
+		}
+	}
+	atomic.StoreUint64(&replace.Zobrist, zobrist^data)
+	atomic.StoreUint64(&replace.Data, data)
+}
+
+func (tt *TranspositionTable) Get(zobrist uint64) (PackedMove, uint8, int32, NodeType, uint8, bool) {
+	b := tt.bucket(zobrist)
+	for i := range b {
+		data := atomic.LoadUint64(&b[i].Data)
+		key := atomic.LoadUint64(&b[i].Zobrist)
+		if key^data == zobrist {
+			bestMove, depth, score, node, age := decodeEntry(data)
+			return bestMove, depth, score, node, age, true
+		}
+	}
+	return 0, 0, 0, 0, 0, false
+}
+
+func decodeEntry(data uint64) (PackedMove, uint8, int32, NodeType, uint8) {
+	bestMoveData := data & Mask16bit
+	depthData := (data >> 16) & Mask8bit
+	scoreData := (data >> 24) & Mask24bit
 	nodeData := (data >> 48) & Mask8bit
 	ageData := (data >> 56) & Mask8bit
-	return uint8(bestMoveIndexData), uint8(depthData), *(*int32)(unsafe.Pointer(&scoreData)), NodeType(nodeData), uint8(ageData), matching
+	//Shifting the sign bit of the 24-bit score to the top and back extends it
+	score := int32(uint32(scoreData)<<8) >> 8
+	return PackedMove(bestMoveData), uint8(depthData), score, NodeType(nodeData), uint8(ageData)
+}
+
+// Hashfull estimates how many permille of the table are used by the search of the given age
+func (tt *TranspositionTable) Hashfull(age uint8) int {
+	used, sampled := 0, 0
+	for i := 0; i < len(tt.buckets) && sampled < hashfullSample; i++ {
+		for j := range tt.buckets[i] {
+			data := atomic.LoadUint64(&tt.buckets[i][j].Data)
+			_, _, _, node, entryAge := decodeEntry(data)
+			if node != 0 && entryAge == age {
+				used++
+			}
+			sampled++
+		}
+	}
+	return used * 1000 / sampled
 }
@@ -0,0 +1,51 @@
+package bitboard
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidUCIMove = errors.New("invalid UCI move")
+
+var uciToPiece map[byte]PieceType = map[byte]PieceType{
+	'q': WhiteQueen, 'r': WhiteRook, 'b': WhiteBishop, 'n': WhiteKnight,
+}
+
+// UCI returns the move in long algebraic notation, like e2e4 or e7e8q.
+// Castling is written as the king move, e1g1, and the null move as 0000.
+func (m Move) UCI() string {
+	if m.From == 0 {
+		return "0000"
+	}
+	uci := squareToString(m.FromIndex) + squareToString(m.ToIndex)
+	if m.PawnPromotionPiece != 0 {
+		uci += string(pieceToFen[whitePiece(m.PawnPromotionPiece)] - 'A' + 'a')
+	}
+	return uci
+}
+
+func (board *ChessBoard) ParseUCIMove(uci string) (Move, error) {
+	if len(uci) != 4 && len(uci) != 5 {
+		return Move{}, fmt.Errorf("uci move %q: %w", uci, ErrInvalidUCIMove)
+	}
+	from, okFrom := stringToSquare(uci[0:2])
+	to, okTo := stringToSquare(uci[2:4])
+	if !okFrom || !okTo {
+		return Move{}, fmt.Errorf("uci move %q: %w", uci, ErrInvalidUCIMove)
+	}
+	var promotion PieceType
+	if len(uci) == 5 {
+		piece, ok := uciToPiece[uci[4]]
+		if !ok {
+			return Move{}, fmt.Errorf("uci move %q: %w", uci, ErrInvalidUCIMove)
+		}
+		promotion = piece
+	}
+
+	for _, m := range board.LegalMoves() {
+		if m.FromIndex == from && m.ToIndex == to && whitePiece(m.PawnPromotionPiece) == promotion {
+			return m, nil
+		}
+	}
+	return Move{}, fmt.Errorf("uci move %q: %w", uci, ErrIllegalMove)
+}
@@ -0,0 +1,82 @@
+package bitboard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUCIMove(t *testing.T) {
+	tests := []struct {
+		fen, uci  string
+		piece     PieceType
+		promotion PieceType
+		short     bool
+		long      bool
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", WhitePawn, 0, false, false},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "g1f3", WhiteKnight, 0, false, false},
+		//Castling is the king move, not king takes rook
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", WhiteKing, 0, true, false},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1c1", WhiteKing, 0, false, true},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8g8", BlackKing, 0, true, false},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", BlackKing, 0, false, true},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1f1", WhiteKing, 0, false, false},
+		{"1n2k3/P7/8/8/8/8/7p/4K1N1 w - - 0 1", "a7a8q", WhitePawn, WhiteQueen, false, false},
+		{"1n2k3/P7/8/8/8/8/7p/4K1N1 w - - 0 1", "a7b8n", WhitePawn, WhiteKnight, false, false},
+		{"1n2k3/P7/8/8/8/8/7p/4K1N1 b - - 0 1", "h2g1r", BlackPawn, BlackRook, false, false},
+		{"1n2k3/P7/8/8/8/8/7p/4K1N1 b - - 0 1", "h2h1b", BlackPawn, BlackBishop, false, false},
+	}
+
+	for _, test := range tests {
+		board, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := board.ParseUCIMove(test.uci)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.fen, test.uci, err)
+			continue
+		}
+		if m.Piece != test.piece || m.PawnPromotionPiece != test.promotion || m.ShortCastle != test.short || m.LongCastle != test.long {
+			t.Errorf("%s %s: got %+v", test.fen, test.uci, m)
+		}
+		if uci := m.UCI(); uci != test.uci {
+			t.Errorf("%s %s: written as %s", test.fen, test.uci, uci)
+		}
+	}
+
+	if uci := (Move{}).UCI(); uci != "0000" {
+		t.Errorf("null move written as %s", uci)
+	}
+}
+
+func TestParseUCIMoveErrors(t *testing.T) {
+	tests := []struct {
+		fen, uci string
+		err      error
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "", ErrInvalidUCIMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2", ErrInvalidUCIMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4e5", ErrInvalidUCIMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2i4", ErrInvalidUCIMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "E2E4", ErrInvalidUCIMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "0000", ErrInvalidUCIMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e5", ErrIllegalMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e7e5", ErrIllegalMove},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4q", ErrIllegalMove},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1h1", ErrIllegalMove},
+		{"1n2k3/P7/8/8/8/8/7p/4K1N1 w - - 0 1", "a7a8", ErrIllegalMove},
+		{"1n2k3/P7/8/8/8/8/7p/4K1N1 w - - 0 1", "a7a8k", ErrInvalidUCIMove},
+		{"1n2k3/P7/8/8/8/8/7p/4K1N1 w - - 0 1", "a7a8Q", ErrInvalidUCIMove},
+	}
+
+	for _, test := range tests {
+		board, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := board.ParseUCIMove(test.uci); !errors.Is(err, test.err) {
+			t.Errorf("%s %q: got %v, want %v", test.fen, test.uci, err, test.err)
+		}
+	}
+}
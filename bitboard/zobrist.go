@@ -28,8 +28,8 @@ func init() {
 
 func boardToHash(board *ChessBoard) uint64 {
 	hash := uint64(0)
-	for i, piece := range board.AllBitboards {
-		for _, pos := range BitboardToSlice(*piece) {
+	for i, piece := range board.pieceBitboards() {
+		for _, pos := range BitboardToSlice(piece) {
 			hash ^= positionHashes[i][pos]
 		}
 	}
@@ -48,6 +48,12 @@ func boardToHash(board *ChessBoard) uint64 {
 	if board.BlackShortCastle {
 		hash ^= blackShortCastleHash
 	}
+	if board.WhiteEnPassant < 8 {
+		hash ^= enPassantHashes[board.WhiteEnPassant]
+	}
+	if board.BlackEnPassant < 8 {
+		hash ^= enPassantHashes[board.BlackEnPassant]
+	}
 
 	return hash
 }
@@ -0,0 +1,84 @@
+// Command chessbot-engine runs the engine without the graphical interface, so it works on machines without a display.
+//
+// Usage:
+//
+//	chessbot-engine uci
+//	chessbot-engine xboard
+//	chessbot-engine [-threads n] [-hash mb] perft <fen|startpos> <depth>
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/oyberntzen/chessbot/bitboard"
+	"github.com/oyberntzen/chessbot/uci"
+	"github.com/oyberntzen/chessbot/xboard"
+)
+
+const startPosition = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
+var perftThreads = flag.Int("threads", runtime.NumCPU(), "number of goroutines used by perft")
+var perftHash = flag.Int("hash", 64, "perft hash table size in megabytes, 0 disables it")
+
+// perft prints the number of leaf nodes below every legal move of the position, to compare move generation with other engines.
+// The position is a FEN or startpos.
+func perft(fen string, depthArg string) error {
+	depth, err := strconv.Atoi(depthArg)
+	if err != nil || depth < 1 {
+		return fmt.Errorf("perft: invalid depth %q", depthArg)
+	}
+	if fen == "startpos" {
+		fen = startPosition
+	}
+	board, err := bitboard.ParseFEN(fen)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	total := uint64(0)
+	for _, count := range bitboard.ParallelDivide(&board, depth, *perftThreads, *perftHash) {
+		fmt.Printf("%s: %d\n", count.Move.UCI(), count.Nodes)
+		total += count.Nodes
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("\nNodes searched: %d\n", total)
+	fmt.Printf("Time: %v\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("Mnps: %.2f\n", float64(total)/elapsed.Seconds()/1e6)
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	if *cpuprofile != "" {
+		f, err := os.Create(*cpuprofile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pprof.StartCPUProfile(f)
+		defer pprof.StopCPUProfile()
+	}
+
+	var err error
+	switch flag.Arg(0) {
+	case "uci":
+		err = uci.Run(os.Stdin, os.Stdout)
+	case "xboard":
+		err = xboard.Run(os.Stdin, os.Stdout)
+	case "perft":
+		err = perft(flag.Arg(1), flag.Arg(2))
+	default:
+		fmt.Fprintln(os.Stderr, "usage: chessbot-engine uci | xboard | perft <fen|startpos> <depth>")
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,5 +1,13 @@
 module github.com/oyberntzen/chessbot
 
-go 1.14
+go 1.18
 
 require github.com/hajimehoshi/ebiten v1.12.8
+
+require (
+	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20200707082815-5321531c36a2 // indirect
+	golang.org/x/exp v0.0.0-20190731235908-ec7cb31e5a56 // indirect
+	golang.org/x/image v0.0.0-20200801110659-972c09e46d76 // indirect
+	golang.org/x/mobile v0.0.0-20210208171126-f462b3930c8f // indirect
+	golang.org/x/sys v0.0.0-20200918174421-af09f7315aff // indirect
+)
@@ -1,10 +1,13 @@
 package graphics
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/color"
+	_ "image/png"
 	"os"
+	"time"
 
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
@@ -15,17 +18,31 @@ var darkColor color.RGBA = color.RGBA{168, 121, 101, 255}
 var lightColor color.RGBA = color.RGBA{240, 216, 192, 255}
 var moveColor color.RGBA = color.RGBA{150, 255, 150, 255}
 var markColor color.RGBA = color.RGBA{255, 255, 150, 255}
+var hangingColor color.RGBA = color.RGBA{230, 110, 110, 255}
 
 var pieceImages [12]*ebiten.Image
 
 var marked bitboard.Bitboard
 var moves bitboard.Bitboard
 
+// hanging marks the player's pieces the engine could win material from
+var hanging bitboard.Bitboard
+
 var pressed bool
 
 var pawnPromotion bool
 var promotionMoves []bitboard.Move
 
+var gameOver bool
+
+// engine is created for the first move it has to answer, so importing the package does not allocate its hash table
+var engine *bitboard.Engine
+
+// The engine plays with a clock of its own so it spends its time sensibly over a whole game
+var engineClock time.Duration = 10 * time.Minute
+
+const engineIncrement time.Duration = 5 * time.Second
+
 const (
 	whitePawnImage   int = 5
 	whiteRookImage   int = 4
@@ -41,19 +58,31 @@ const (
 	blackKingImage   int = 6
 )
 
-func init() {
-	file, _ := os.Open("./pieces.png")
-	img, _, _ := image.Decode(file)
+// LoadPieces loads the piece sprite sheet, which must be loaded before the first frame is drawn
+func LoadPieces(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return err
+	}
 	i := 0
 	for y := 0; y < 50*2; y += 50 {
 		for x := 0; x < 50*6; x += 50 {
 			subImg := img.(interface {
 				SubImage(r image.Rectangle) image.Image
 			}).SubImage(image.Rect(x, y, x+50, y+50))
-			pieceImages[i], _ = ebiten.NewImageFromImage(subImg, ebiten.FilterDefault)
+			pieceImages[i], err = ebiten.NewImageFromImage(subImg, ebiten.FilterDefault)
+			if err != nil {
+				return err
+			}
 			i++
 		}
 	}
+	return nil
 }
 
 func drawBitBoard(screen *ebiten.Image, board bitboard.Bitboard, posColor color.RGBA, negColor color.RGBA) {
@@ -79,6 +108,7 @@ func DrawBoard(screen *ebiten.Image) {
 			}
 		}
 	}
+	drawBitBoard(screen, hanging, hangingColor, color.RGBA{0, 0, 0, 0})
 	drawBitBoard(screen, marked, markColor, color.RGBA{0, 0, 0, 0})
 	drawBitBoard(screen, moves, moveColor, color.RGBA{0, 0, 0, 0})
 }
@@ -124,6 +154,9 @@ func DrawPieces(screen *ebiten.Image, board *bitboard.ChessBoard) {
 }
 
 func HandleInput(board *bitboard.ChessBoard) {
+	if gameOver {
+		return
+	}
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 		if !pressed {
 			if !pawnPromotion {
@@ -133,7 +166,7 @@ func HandleInput(board *bitboard.ChessBoard) {
 					newMarked := bitboard.CoordsToBitboard(x/50, y/50)
 					var psudomoves []bitboard.Move
 					var validMove bool
-					for _, m := range board.PsudoLegalMoves(false) {
+					for _, m := range board.LegalMoves() {
 						if m.From == marked && m.To == newMarked {
 							psudomoves = append(psudomoves, m)
 							validMove = true
@@ -155,7 +188,7 @@ func HandleInput(board *bitboard.ChessBoard) {
 						} else {
 							marked = newMarked
 							moves = bitboard.Bitboard(0)
-							for _, m := range board.PsudoLegalMoves(false) {
+							for _, m := range board.LegalMoves() {
 								if marked == m.From {
 									moves |= m.To
 								}
@@ -199,26 +232,38 @@ func HandleInput(board *bitboard.ChessBoard) {
 }
 
 func doMove(board *bitboard.ChessBoard, m bitboard.Move) {
-	temp := *board
 	board.DoMove(m)
-	if !board.CheckForCheck(board.BlacksTurn) {
-		marked = 0
-		moves = 0
-		m := bitboard.IterativeDeepening(board)
-		board.DoMove(m)
-	} else {
-		*board = temp
-		if m.To == marked {
-			marked = 0
-			moves = 0
-		} else {
-			marked = m.To
-			moves = bitboard.Bitboard(0)
-			for _, m := range board.PsudoLegalMoves(false) {
-				if marked == m.From {
-					moves |= m.To
-				}
-			}
-		}
+	marked = 0
+	moves = 0
+	hanging = 0
+	if checkGameOver(board) {
+		return
+	}
+	if engine == nil {
+		engine = bitboard.NewEngine(bitboard.Options{})
+	}
+	start := time.Now()
+	limits := bitboard.Limits{
+		WhiteTime:      engineClock,
+		BlackTime:      engineClock,
+		WhiteIncrement: engineIncrement,
+		BlackIncrement: engineIncrement,
+	}
+	result := engine.IterativeDeepening(context.Background(), board, limits, nil)
+	engineClock += engineIncrement - time.Since(start)
+	fmt.Printf("Depth: %v\n", result.Depth)
+	board.DoMove(result.BestMove)
+	if !checkGameOver(board) {
+		hanging = board.HangingPieces()
+	}
+}
+
+func checkGameOver(board *bitboard.ChessBoard) bool {
+	outcome := board.Outcome()
+	if outcome.Result == bitboard.Ongoing {
+		return false
 	}
+	gameOver = true
+	fmt.Printf("%v (%v)\n", outcome.Result, outcome.Termination)
+	return true
 }
@@ -11,6 +11,8 @@ import (
 	"github.com/oyberntzen/chessbot/graphics"
 )
 
+const startPosition = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
 type game struct {
 	board bitboard.ChessBoard
 }
@@ -44,11 +46,16 @@ func main() {
 		pprof.StartCPUProfile(f)
 		defer pprof.StopCPUProfile()
 	}
+
+	if err := graphics.LoadPieces("./pieces.png"); err != nil {
+		log.Fatal(err)
+	}
 	ebiten.SetWindowSize(400, 400)
-	g := game{}
-	g.board = bitboard.FenString("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -")
-	g.board.Init()
-	g.board.InitVariables()
+	board, err := bitboard.ParseFEN(startPosition)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g := game{board: board}
 
 	if err := ebiten.RunGame(&g); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,292 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oyberntzen/chessbot/bitboard"
+)
+
+const startPosition = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+type engine struct {
+	out   io.Writer
+	mutex sync.Mutex
+
+	board  bitboard.ChessBoard
+	search *bitboard.Engine
+
+	moveOverhead time.Duration
+
+	cancel    context.CancelFunc
+	stop      chan struct{}
+	done      chan struct{}
+	ponderhit chan struct{}
+}
+
+// Run speaks the Universal Chess Interface, reading commands from in and writing responses to out until quit is received or in is closed
+func Run(in io.Reader, out io.Writer) error {
+	e := &engine{out: out, search: bitboard.NewEngine(bitboard.Options{}), moveOverhead: bitboard.DefaultMoveOverhead}
+	e.setPosition(startPosition, nil)
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			e.send("id name chessbot")
+			e.send("id author oyberntzen")
+			e.send("option name Hash type spin default %d min 1 max %d", bitboard.DefaultHashSize, bitboard.MaxHashSize)
+			e.send("option name Ponder type check default false")
+			e.send("option name Clear Hash type button")
+			e.send("option name MultiPV type spin default 1 min 1 max %d", bitboard.MaxMultiPV)
+			e.send("option name Threads type spin default 1 min 1 max %d", bitboard.MaxThreads)
+			for _, feature := range bitboard.Features {
+				e.send("option name %s type check default true", feature)
+			}
+			e.send("option name Move Overhead type spin default %d min 0 max 5000", bitboard.DefaultMoveOverhead.Milliseconds())
+			e.send("uciok")
+		case "isready":
+			e.send("readyok")
+		case "ucinewgame":
+			e.stopSearch()
+			e.search.NewGame()
+			e.setPosition(startPosition, nil)
+		case "position":
+			e.stopSearch()
+			e.position(fields[1:])
+		case "go":
+			e.stopSearch()
+			e.goSearch(fields[1:])
+		case "stop":
+			e.stopSearch()
+		case "ponderhit":
+			//The opponent played the expected move, the search goes on under the normal time limits
+			if e.ponderhit != nil {
+				close(e.ponderhit)
+				e.ponderhit = nil
+			}
+		case "setoption":
+			e.stopSearch()
+			e.setOption(fields[1:])
+		case "quit":
+			e.stopSearch()
+			return nil
+		case "debug", "register":
+		default:
+			e.send("info string unknown command %s", fields[0])
+		}
+	}
+	e.stopSearch()
+	return scanner.Err()
+}
+
+func (e *engine) send(format string, args ...interface{}) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+func (e *engine) setPosition(fen string, moves []string) error {
+	board, err := bitboard.ParseFEN(fen)
+	if err != nil {
+		return err
+	}
+	for _, text := range moves {
+		m, err := board.ParseUCIMove(text)
+		if err != nil {
+			return err
+		}
+		board.DoMove(m)
+	}
+	e.board = board
+	return nil
+}
+
+func (e *engine) position(args []string) {
+	var fen string
+	var moves []string
+	for i, arg := range args {
+		if arg == "moves" {
+			moves = args[i+1:]
+			args = args[:i]
+			break
+		}
+	}
+	if len(args) > 0 && args[0] == "startpos" {
+		fen = startPosition
+	} else if len(args) > 1 && args[0] == "fen" {
+		fen = strings.Join(args[1:], " ")
+	} else {
+		e.send("info string expected startpos or fen")
+		return
+	}
+	if err := e.setPosition(fen, moves); err != nil {
+		e.send("info string %v", err)
+	}
+}
+
+func (e *engine) goSearch(args []string) {
+	limits := bitboard.Limits{MoveOverhead: e.moveOverhead}
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" {
+			limits.Infinite = true
+			continue
+		}
+		if args[i] == "ponder" {
+			e.ponderhit = make(chan struct{})
+			limits.Ponder = e.ponderhit
+			continue
+		}
+		if i+1 >= len(args) {
+			break
+		}
+		value, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			continue
+		}
+		switch args[i] {
+		case "wtime":
+			limits.WhiteTime = time.Duration(value) * time.Millisecond
+		case "btime":
+			limits.BlackTime = time.Duration(value) * time.Millisecond
+		case "winc":
+			limits.WhiteIncrement = time.Duration(value) * time.Millisecond
+		case "binc":
+			limits.BlackIncrement = time.Duration(value) * time.Millisecond
+		case "movestogo":
+			limits.MovesToGo = value
+		case "depth":
+			limits.Depth = value
+		case "nodes":
+			limits.Nodes = uint64(value)
+		case "movetime":
+			limits.MoveTime = time.Duration(value) * time.Millisecond
+		case "mate":
+			limits.Mate = value
+		default:
+			continue
+		}
+		i++
+	}
+
+	board := e.board
+	board.LastHashes = append([]uint64(nil), e.board.LastHashes...)
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		result := e.search.IterativeDeepening(ctx, &board, limits, e.info)
+		//The protocol does not allow bestmove before stop in infinite mode, or before ponderhit or stop while pondering
+		if limits.Infinite {
+			<-stop
+		} else if limits.Ponder != nil {
+			select {
+			case <-stop:
+			case <-limits.Ponder:
+			}
+		}
+		if result.PonderMove.From != 0 {
+			e.send("bestmove %s ponder %s", result.BestMove.UCI(), result.PonderMove.UCI())
+		} else {
+			e.send("bestmove %s", result.BestMove.UCI())
+		}
+	}(e.stop, e.done)
+}
+
+func (e *engine) info(info bitboard.SearchResult) {
+	pv := make([]string, len(info.PV))
+	for i, m := range info.PV {
+		pv[i] = m.UCI()
+	}
+	nps := uint64(0)
+	if info.Time > 0 {
+		nps = uint64(float64(info.Nodes) / info.Time.Seconds())
+	}
+	score := fmt.Sprintf("cp %d", info.Score)
+	if info.Mate != 0 {
+		score = fmt.Sprintf("mate %d", info.Mate)
+	}
+	e.send("info depth %d seldepth %d multipv %d score %s nodes %d nps %d hashfull %d time %d pv %s",
+		info.Depth, info.SelDepth, info.MultiPV, score, info.Nodes, nps, info.Hashfull, info.Time.Milliseconds(), strings.Join(pv, " "))
+}
+
+func (e *engine) stopSearch() {
+	if e.done == nil {
+		return
+	}
+	e.cancel()
+	close(e.stop)
+	<-e.done
+	e.cancel = nil
+	e.stop = nil
+	e.done = nil
+	e.ponderhit = nil
+}
+
+func (e *engine) setOption(args []string) {
+	var name, value []string
+	current := &name
+	for _, arg := range args {
+		if arg == "name" {
+			current = &name
+		} else if arg == "value" {
+			current = &value
+		} else {
+			*current = append(*current, arg)
+		}
+	}
+	switch strings.ToLower(strings.Join(name, " ")) {
+	case "hash":
+		megabytes, err := strconv.Atoi(strings.Join(value, " "))
+		if err != nil || megabytes < 1 || megabytes > bitboard.MaxHashSize {
+			e.send("info string invalid value for Hash")
+			return
+		}
+		e.search.SetHash(megabytes)
+	case "clear hash":
+		e.search.NewGame()
+	case "ponder":
+		//Nothing to set up, the GUI decides when to ponder with go ponder
+	case "multipv":
+		lines, err := strconv.Atoi(strings.Join(value, " "))
+		if err != nil || lines < 1 || lines > bitboard.MaxMultiPV {
+			e.send("info string invalid value for MultiPV")
+			return
+		}
+		e.search.SetMultiPV(lines)
+	case "threads":
+		threads, err := strconv.Atoi(strings.Join(value, " "))
+		if err != nil || threads < 1 || threads > bitboard.MaxThreads {
+			e.send("info string invalid value for Threads")
+			return
+		}
+		e.search.SetThreads(threads)
+	case "move overhead":
+		milliseconds, err := strconv.Atoi(strings.Join(value, " "))
+		if err != nil || milliseconds < 0 {
+			e.send("info string invalid value for Move Overhead")
+			return
+		}
+		e.moveOverhead = time.Duration(milliseconds) * time.Millisecond
+	default:
+		for _, feature := range bitboard.Features {
+			if strings.EqualFold(feature.String(), strings.Join(name, " ")) {
+				e.search.SetFeature(feature, strings.EqualFold(strings.Join(value, " "), "true"))
+				return
+			}
+		}
+		e.send("info string unknown option %s", strings.Join(name, " "))
+	}
+}
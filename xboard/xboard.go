@@ -0,0 +1,350 @@
+package xboard
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oyberntzen/chessbot/bitboard"
+)
+
+const startPosition = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// harmless commands can be handled without stopping a running search, any other command aborts it
+var harmless map[string]bool = map[string]bool{
+	"ping": true, "post": true, "nopost": true, "time": true, "otim": true, ".": true, "draw": true,
+	"hard": true, "easy": true, "computer": true, "accepted": true, "rejected": true, "random": true,
+}
+
+type engine struct {
+	out   io.Writer
+	mutex sync.Mutex
+
+	startFen string
+	moves    []bitboard.Move
+	board    bitboard.ChessBoard
+	search   *bitboard.Engine
+
+	force bool
+	post  bool
+
+	movesPerSession int
+	increment       time.Duration
+	moveTime        time.Duration
+	depth           int
+	engineTime      time.Duration
+	opponentTime    time.Duration
+
+	cancel  context.CancelFunc
+	done    chan struct{}
+	aborted bool
+}
+
+// Run speaks the Chess Engine Communication Protocol used by xboard and WinBoard,
+// reading commands from in and writing responses to out until quit is received or in is closed
+func Run(in io.Reader, out io.Writer) error {
+	e := &engine{out: out, search: bitboard.NewEngine(bitboard.Options{})}
+	e.newGame()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "?" {
+			e.moveNow()
+			continue
+		}
+		if !harmless[fields[0]] {
+			e.abortSearch()
+		}
+
+		e.mutex.Lock()
+		quit := e.command(fields)
+		e.mutex.Unlock()
+		if quit {
+			return nil
+		}
+	}
+	e.abortSearch()
+	return scanner.Err()
+}
+
+// command handles one line of input, the caller holds the mutex
+func (e *engine) command(fields []string) bool {
+	args := fields[1:]
+	switch fields[0] {
+	case "xboard", "accepted", "rejected", "random", "hard", "easy", "computer", "white", "black", "draw", "otherboard", ".":
+	case "protover":
+		e.send("feature myname=\"chessbot\" usermove=1 setboard=1 ping=1 playother=1 memory=1 smp=1 sigint=0 sigterm=0 colors=0 done=1")
+	case "ping":
+		e.send("pong %s", strings.Join(args, " "))
+	case "new":
+		e.search.NewGame()
+		e.newGame()
+	case "force":
+		e.force = true
+	case "go":
+		e.force = false
+		e.think()
+	case "playother":
+		e.force = false
+	case "usermove":
+		if len(args) > 0 {
+			e.userMove(args[0])
+		}
+	case "level":
+		e.level(args)
+	case "st":
+		if len(args) > 0 {
+			if seconds, err := strconv.Atoi(args[0]); err == nil {
+				e.moveTime = time.Duration(seconds) * time.Second
+			}
+		}
+	case "sd":
+		if len(args) > 0 {
+			if depth, err := strconv.Atoi(args[0]); err == nil {
+				e.depth = depth
+			}
+		}
+	case "time":
+		e.engineTime = centiseconds(args)
+	case "otim":
+		e.opponentTime = centiseconds(args)
+	case "memory":
+		if len(args) > 0 {
+			if megabytes, err := strconv.Atoi(args[0]); err == nil {
+				e.search.SetHash(megabytes)
+			}
+		}
+	case "cores":
+		if len(args) > 0 {
+			if threads, err := strconv.Atoi(args[0]); err == nil {
+				e.search.SetThreads(threads)
+			}
+		}
+	case "undo":
+		e.takeBack(1)
+	case "remove":
+		e.takeBack(2)
+	case "setboard":
+		if err := e.setPosition(strings.Join(args, " ")); err != nil {
+			e.send("tellusererror Illegal position: %v", err)
+		}
+	case "post":
+		e.post = true
+	case "nopost":
+		e.post = false
+	case "result":
+		e.force = true
+	case "quit":
+		return true
+	default:
+		//Without usermove=1 moves arrive as bare words
+		if _, err := e.board.ParseUCIMove(fields[0]); err == nil {
+			e.userMove(fields[0])
+		} else if _, err := e.board.ParseSAN(fields[0]); err == nil {
+			e.userMove(fields[0])
+		} else {
+			e.send("Error (unknown command): %s", fields[0])
+		}
+	}
+	return false
+}
+
+func (e *engine) send(format string, args ...interface{}) {
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+func (e *engine) newGame() {
+	e.setPosition(startPosition)
+	e.force = false
+	e.moveTime = 0
+	e.depth = 0
+}
+
+func (e *engine) setPosition(fen string) error {
+	board, err := bitboard.ParseFEN(fen)
+	if err != nil {
+		return err
+	}
+	e.startFen = fen
+	e.moves = nil
+	e.board = board
+	return nil
+}
+
+func (e *engine) takeBack(count int) {
+	if count > len(e.moves) {
+		count = len(e.moves)
+	}
+	moves := e.moves[:len(e.moves)-count]
+	e.setPosition(e.startFen)
+	for _, m := range moves {
+		e.board.DoMove(m)
+	}
+	e.moves = moves
+}
+
+func (e *engine) level(args []string) {
+	if len(args) < 3 {
+		return
+	}
+	e.movesPerSession, _ = strconv.Atoi(args[0])
+	var minutes, seconds int
+	if parts := strings.SplitN(args[1], ":", 2); len(parts) == 2 {
+		minutes, _ = strconv.Atoi(parts[0])
+		seconds, _ = strconv.Atoi(parts[1])
+	} else {
+		minutes, _ = strconv.Atoi(args[1])
+	}
+	e.engineTime = time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	e.opponentTime = e.engineTime
+	increment, _ := strconv.ParseFloat(args[2], 64)
+	e.increment = time.Duration(increment * float64(time.Second))
+	e.moveTime = 0
+}
+
+func centiseconds(args []string) time.Duration {
+	if len(args) == 0 {
+		return 0
+	}
+	value, _ := strconv.Atoi(args[0])
+	return time.Duration(value) * 10 * time.Millisecond
+}
+
+func (e *engine) userMove(text string) {
+	m, err := e.board.ParseUCIMove(text)
+	if err != nil {
+		m, err = e.board.ParseSAN(text)
+	}
+	if err != nil {
+		e.send("Illegal move: %s", text)
+		return
+	}
+	e.play(m)
+	if !e.force && !e.gameOver() {
+		e.think()
+	}
+}
+
+func (e *engine) play(m bitboard.Move) {
+	e.board.DoMove(m)
+	e.moves = append(e.moves, m)
+}
+
+func (e *engine) gameOver() bool {
+	outcome := e.board.Outcome()
+	switch outcome.Termination {
+	case 0:
+		return false
+	case bitboard.Checkmate:
+		if outcome.Result == bitboard.WhiteWins {
+			e.send("1-0 {White mates}")
+		} else {
+			e.send("0-1 {Black mates}")
+		}
+	default:
+		e.send("1/2-1/2 {%s}", outcome.Termination)
+	}
+	return true
+}
+
+func (e *engine) limits() bitboard.Limits {
+	limits := bitboard.Limits{Depth: e.depth, MoveTime: e.moveTime, MoveOverhead: bitboard.DefaultMoveOverhead}
+	if e.moveTime == 0 && (e.engineTime != 0 || e.opponentTime != 0) {
+		limits.WhiteTime, limits.BlackTime = e.engineTime, e.opponentTime
+		if e.board.BlacksTurn {
+			limits.WhiteTime, limits.BlackTime = e.opponentTime, e.engineTime
+		}
+		limits.WhiteIncrement, limits.BlackIncrement = e.increment, e.increment
+		if e.movesPerSession > 0 {
+			limits.MovesToGo = e.movesPerSession - (e.board.FullmoveNumber-1)%e.movesPerSession
+		}
+	}
+	return limits
+}
+
+// think starts searching for a move for the side to move, the move is played when the search ends unless it is aborted
+func (e *engine) think() {
+	if e.gameOver() {
+		return
+	}
+	board := e.board
+	board.LastHashes = append([]uint64(nil), e.board.LastHashes...)
+	limits := e.limits()
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	e.done = make(chan struct{})
+	e.aborted = false
+	go func(done chan struct{}) {
+		defer close(done)
+		result := e.search.IterativeDeepening(ctx, &board, limits, func(info bitboard.SearchResult) {
+			e.mutex.Lock()
+			defer e.mutex.Unlock()
+			if e.post {
+				e.send("%d %d %d %d %s", info.Depth, score(info), info.Time.Milliseconds()/10, info.Nodes, e.pvString(info.PV))
+			}
+		})
+
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		if e.aborted {
+			return
+		}
+		e.play(result.BestMove)
+		e.send("move %s", result.BestMove.UCI())
+		e.gameOver()
+	}(e.done)
+}
+
+// score uses the protocol convention of 100000+N for mate in N and -100000-N for getting mated in N
+func score(info bitboard.SearchResult) int32 {
+	if info.Mate > 0 {
+		return 100000 + int32(info.Mate)
+	}
+	if info.Mate < 0 {
+		return -100000 + int32(info.Mate)
+	}
+	return info.Score
+}
+
+func (e *engine) pvString(pv []bitboard.Move) string {
+	board := e.board
+	board.LastHashes = append([]uint64(nil), e.board.LastHashes...)
+	san := make([]string, len(pv))
+	for i, m := range pv {
+		san[i] = board.MoveToSAN(m)
+		board.DoMove(m)
+	}
+	return strings.Join(san, " ")
+}
+
+// moveNow makes a running search play its best move so far
+func (e *engine) moveNow() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+// abortSearch stops a running search without playing or sending its move
+func (e *engine) abortSearch() {
+	if e.done == nil {
+		return
+	}
+	e.mutex.Lock()
+	e.aborted = true
+	e.cancel()
+	e.mutex.Unlock()
+	<-e.done
+	e.done = nil
+	e.cancel = nil
+}
@@ -0,0 +1,116 @@
+package xboard
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// session runs the protocol over pipes, so a test can wait for a response before sending the next command
+type session struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines chan string
+	done  chan error
+}
+
+func newSession(t *testing.T) *session {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	s := &session{t: t, in: inWriter, lines: make(chan string, 1000), done: make(chan error, 1)}
+	go func() {
+		err := Run(inReader, outWriter)
+		outWriter.Close()
+		s.done <- err
+	}()
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+		close(s.lines)
+	}()
+	return s
+}
+
+func (s *session) send(commands ...string) {
+	for _, command := range commands {
+		if _, err := io.WriteString(s.in, command+"\n"); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+}
+
+// waitFor returns the lines up to and including the first one starting with prefix
+func (s *session) waitFor(prefix string) []string {
+	var lines []string
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.t.Fatalf("output ended while waiting for %q", prefix)
+			}
+			lines = append(lines, line)
+			if strings.HasPrefix(line, prefix) {
+				return lines
+			}
+		case <-timeout:
+			s.t.Fatalf("no %q after %q", prefix, lines)
+		}
+	}
+}
+
+func (s *session) close() {
+	s.send("quit")
+	s.in.Close()
+	if err := <-s.done; err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// thinking starts a search that would take far longer than the test, and waits until it has reported a depth
+func (s *session) thinking() {
+	s.send("new", "post", "st 60", "go")
+	s.waitFor("1 ")
+}
+
+func TestAbortDoesNotMove(t *testing.T) {
+	for _, command := range []string{"result 1-0 {resign}", "new", "force"} {
+		s := newSession(t)
+		s.thinking()
+		s.send(command, "ping 1")
+		for _, line := range s.waitFor("pong 1") {
+			if strings.HasPrefix(line, "move ") {
+				t.Errorf("%s during go: engine sent %q", command, line)
+			}
+		}
+		s.close()
+	}
+}
+
+func TestAbortKeepsHistory(t *testing.T) {
+	s := newSession(t)
+	s.thinking()
+	//The aborted search must not add a move, so undo takes back the last move of the game
+	s.send("force", "usermove e2e4", "usermove e7e5", "undo", "usermove c7c5", "ping 1")
+	for _, line := range s.waitFor("pong 1") {
+		if strings.HasPrefix(line, "Illegal move") || strings.HasPrefix(line, "move ") {
+			t.Errorf("unexpected %q", line)
+		}
+	}
+	s.close()
+}
+
+func TestMoveNow(t *testing.T) {
+	s := newSession(t)
+	s.thinking()
+	s.send("?")
+	lines := s.waitFor("move ")
+	if move := lines[len(lines)-1]; len(strings.Fields(move)) != 2 {
+		t.Errorf("? sent %q", move)
+	}
+	s.close()
+}